@@ -0,0 +1,107 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultBaudRate 表计上电后的默认通信速率
+const DefaultBaudRate = 2400
+
+// BaudRateError 不支持的通信速率或通信速率特征字
+var BaudRateError = errors.New("dlt645_2007: unsupported baud rate")
+
+// baudRates 通信速率特征字 D1~D6 对应的通信速率
+var baudRates = []struct {
+	rate    int
+	feature byte
+}{
+	{600, 0x02},
+	{1200, 0x04},
+	{2400, 0x08},
+	{4800, 0x10},
+	{9600, 0x20},
+	{19200, 0x40},
+}
+
+// BaudRateFeature 获取通信速率对应的通信速率特征字
+// rate 通信速率，600、1200、2400、4800、9600、19200
+func BaudRateFeature(rate int) (byte, error) {
+	for _, item := range baudRates {
+		if item.rate == rate {
+			return item.feature, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %d", BaudRateError, rate)
+}
+
+// BaudRateFromFeature 获取通信速率特征字对应的通信速率，特征字只能有一位为1
+// feature 通信速率特征字
+func BaudRateFromFeature(feature byte) (int, error) {
+	for _, item := range baudRates {
+		if item.feature == feature {
+			return item.rate, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: feature %02X", BaudRateError, feature)
+}
+
+// BaudRateSetterError 传输层没有实现 BaudRateSetter，不能切换通信速率
+var BaudRateSetterError = errors.New("dlt645_2007: transport does not implement BaudRateSetter")
+
+// BaudRateSetter 可以切换通信速率的传输层，例如串口
+type BaudRateSetter interface {
+	SetBaudRate(rate int) error
+}
+
+// ChangeBaud 变更通信速率，表计以原速率应答后传输层切换到新速率，应答和切换期间不会发送其他请求；
+// 传输层没有实现 BaudRateSetter 时不发送请求，返回 BaudRateSetterError，例如透明传输的网络模块；
+// 广播地址的表计不应答，请求发出后等待广播后的静默时间再切换
+// ctx 上下文，取消时中止等待应答
+// rate 新的通信速率
+func (c *Client) ChangeBaud(ctx context.Context, rate int) error {
+	setter, ok := c.transport.(BaudRateSetter)
+	if !ok {
+		return BaudRateSetterError
+	}
+	frame, err := BuildBaudChangeRequest(c.meter.prefix, c.meter.address, rate)
+	if err != nil {
+		return err
+	}
+	if err = c.lock(ctx); err != nil {
+		return err
+	}
+	defer c.unlock()
+	resp, err := c.request(ctx, frame)
+	if err != nil {
+		return err
+	}
+	if resp == nil {
+		//广播命令表计不应答，等待广播后的静默时间，让表计处理完命令后再切换
+		timer := time.NewTimer(defaultBroadcastSilence)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	} else {
+		if len(resp.Data) != 1 {
+			return DataDomainError
+		}
+		confirmed, err := BaudRateFromFeature(resp.Data[0])
+		if err != nil {
+			return err
+		}
+		if confirmed != rate {
+			return fmt.Errorf("%w: meter confirmed %d instead of %d", BaudRateError, confirmed, rate)
+		}
+	}
+	if err = setter.SetBaudRate(rate); err != nil {
+		return err
+	}
+	c.reader.Reset(c.transport)
+	return nil
+}
@@ -0,0 +1,89 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// baudConn 记录切换后的通信速率
+type baudConn struct {
+	net.Conn
+	rate int
+}
+
+func (c *baudConn) SetBaudRate(rate int) error {
+	c.rate = rate
+	return nil
+}
+
+func TestBaudRateFeature(t *testing.T) {
+	feature, err := BaudRateFeature(9600)
+	if err != nil || feature != 0x20 {
+		t.Fatalf("unexpected feature %02X %v", feature, err)
+	}
+	if _, err = BaudRateFeature(115200); !errors.Is(err, BaudRateError) {
+		t.Fatalf("expected baud rate error, got %v", err)
+	}
+	if _, err = BaudRateFromFeature(0x28); !errors.Is(err, BaudRateError) {
+		t.Fatalf("expected baud rate error, got %v", err)
+	}
+}
+
+func TestClientChangeBaud(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	simulator := NewSimulator("000000013310")
+	go simulator.Serve(slave)
+
+	conn := &baudConn{Conn: master}
+	client := NewClient(NewMeter("", "000000013310"), conn, time.Second)
+	if err := client.ChangeBaud(context.Background(), 9600); err != nil {
+		t.Fatal(err)
+	}
+	if conn.rate != 9600 || simulator.BaudRate() != 9600 {
+		t.Fatalf("unexpected rate %d %d", conn.rate, simulator.BaudRate())
+	}
+
+	//传输层不能切换通信速率时不发送请求
+	plain := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	if err := plain.ChangeBaud(context.Background(), 2400); !errors.Is(err, BaudRateSetterError) {
+		t.Fatalf("expected BaudRateSetterError, got %v", err)
+	}
+	if simulator.BaudRate() != 9600 {
+		t.Fatalf("unexpected rate %d", simulator.BaudRate())
+	}
+
+	//广播地址不应答，等待静默时间后切换
+	broadcast := &baudConn{Conn: master}
+	start := time.Now()
+	if err := NewClient(NewMeter("", BroadcastAddress), broadcast, time.Second).ChangeBaud(context.Background(), 4800); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < defaultBroadcastSilence {
+		t.Fatalf("switched after %v, before the broadcast silence", elapsed)
+	}
+	if broadcast.rate != 4800 || simulator.BaudRate() != 4800 {
+		t.Fatalf("unexpected rate %d %d", broadcast.rate, simulator.BaudRate())
+	}
+
+	//取消时不切换
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	broadcast = &baudConn{Conn: master}
+	if err := NewClient(NewMeter("", BroadcastAddress), broadcast, time.Second).ChangeBaud(ctx, 9600); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if broadcast.rate != 0 {
+		t.Fatalf("unexpected rate %d", broadcast.rate)
+	}
+
+	//不支持的通信速率特征字
+	frame, _ := (&MeterDlt645Protocol{Address: "000000013310", ControlChar: BaudChangeRequest, Data: []byte{0x03}}).Encode()
+	var abnormal *AbnormalResponseError
+	if _, err := client.Request(context.Background(), frame); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x08 {
+		t.Fatalf("expected baud rate error, got %v", err)
+	}
+}
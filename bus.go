@@ -0,0 +1,221 @@
+package go_dlt645_2007
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFrameGap         = 20 * time.Millisecond  //默认的帧间隔
+	defaultBroadcastSilence = 500 * time.Millisecond //默认的广播后静默时间
+)
+
+// BusClosedError 总线调度器已经关闭
+var BusClosedError = errors.New("dlt645_2007 bus: closed")
+
+// Priority 请求优先级，数值大的先发送，同一优先级按提交顺序发送
+type Priority int
+
+const (
+	PriorityPoll      Priority = iota //抄读
+	PriorityControl                   //设置、控制
+	PriorityBroadcast                 //广播、广播校时
+)
+
+// BusResult 总线请求的结果，广播请求的 Response 为 nil
+type BusResult struct {
+	Response *MeterDlt645Protocol
+	Err      error
+}
+
+// busRequest 排队中的请求
+type busRequest struct {
+	ctx       context.Context
+	frame     []byte
+	priority  Priority
+	seq       uint64
+	broadcast bool
+	result    chan BusResult
+}
+
+// busQueue 按优先级和提交顺序排序的请求队列
+type busQueue []*busRequest
+
+func (q busQueue) Len() int { return len(q) }
+
+func (q busQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q busQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *busQueue) Push(x any) { *q = append(*q, x.(*busRequest)) }
+
+func (q *busQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	old[len(old)-1] = nil
+	*q = old[:len(old)-1]
+	return item
+}
+
+// NewBus 创建一个总线调度器，多个协程共用一条半双工485总线时，所有请求都通过调度器依次发送
+// transport 传输层，例如串口、pty 或 net.Conn；实现了 SetReadDeadline 的传输层才支持超时和取消
+// timeout 等待应答的超时时间，0 表示一直等待
+func NewBus(transport io.ReadWriter, timeout time.Duration) *Bus {
+	b := &Bus{client: NewClient(nil, transport, timeout), gap: defaultFrameGap, silence: defaultBroadcastSilence, done: make(chan struct{})}
+	b.cond = sync.NewCond(&b.mu)
+	go b.run()
+	return b
+}
+
+// Bus 总线调度器，请求按优先级排队，广播和广播校时先于抄读发送；
+// 每次应答(或超时)之后等待帧间隔再发送下一帧，广播之后等待静默时间，给表计处理广播命令
+type Bus struct {
+	client  *Client
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   busQueue
+	seq     uint64
+	gap     time.Duration //帧间隔
+	silence time.Duration //广播后的静默时间
+	closed  bool
+	done    chan struct{}
+}
+
+// SetFrameGap 设置帧间隔，上一次应答或超时后至少等待这么久才发送下一帧
+// gap 帧间隔
+func (b *Bus) SetFrameGap(gap time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.gap = gap
+}
+
+// SetBroadcastSilence 设置广播后的静默时间，广播帧发出后至少等待这么久才发送下一帧
+// silence 静默时间
+func (b *Bus) SetBroadcastSilence(silence time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.silence = silence
+}
+
+// Submit 提交一个请求，结果通过返回的通道获取，通道只会收到一个结果
+// ctx 请求在排队或等待帧间隔时取消，结果为 ctx.Err()，报文不会发出；等待应答时取消，立即结束等待
+// frame 由 Build* 系列函数创建的请求帧
+// priority 优先级，广播帧(广播地址或广播校时)至少按 PriorityBroadcast 发送
+func (b *Bus) Submit(ctx context.Context, frame []byte, priority Priority) <-chan BusResult {
+	result := make(chan BusResult, 1)
+	pro := &MeterDlt645Protocol{}
+	if err := pro.ParseFrame(frame); err != nil {
+		result <- BusResult{Err: err}
+		return result
+	}
+	broadcast := pro.Address == BroadcastAddress || pro.ControlChar == BroadcastTimeCalibration
+	if broadcast && priority < PriorityBroadcast {
+		priority = PriorityBroadcast
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		result <- BusResult{Err: BusClosedError}
+		return result
+	}
+	b.seq++
+	heap.Push(&b.queue, &busRequest{ctx: ctx, frame: frame, priority: priority, seq: b.seq, broadcast: broadcast, result: result})
+	b.cond.Signal()
+	return result
+}
+
+// Do 提交一个请求并等待结果，ctx 取消时立即返回 ctx.Err()
+// ctx 上下文
+// frame 由 Build* 系列函数创建的请求帧
+// priority 优先级
+func (b *Bus) Do(ctx context.Context, frame []byte, priority Priority) (*MeterDlt645Protocol, error) {
+	select {
+	case result := <-b.Submit(ctx, frame, priority):
+		return result.Response, result.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Close 关闭调度器，排队中的请求返回 BusClosedError，正在等待应答的请求不受影响
+func (b *Bus) Close() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil
+	}
+	b.closed = true
+	close(b.done)
+	for _, req := range b.queue {
+		req.result <- BusResult{Err: BusClosedError}
+	}
+	b.queue = nil
+	b.cond.Broadcast()
+	return nil
+}
+
+// next 取出优先级最高的请求，调度器关闭时返回 nil
+func (b *Bus) next() *busRequest {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.queue) == 0 && !b.closed {
+		b.cond.Wait()
+	}
+	if b.closed {
+		return nil
+	}
+	return heap.Pop(&b.queue).(*busRequest)
+}
+
+func (b *Bus) run() {
+	var readyAt time.Time //下一帧最早的发送时间
+	for {
+		req := b.next()
+		if req == nil {
+			return
+		}
+		if err := b.wait(req.ctx, readyAt); err != nil {
+			req.result <- BusResult{Err: err}
+			continue
+		}
+		resp, err := b.client.Request(req.ctx, req.frame)
+		b.mu.Lock()
+		interval := b.gap
+		if req.broadcast {
+			interval = b.silence
+		}
+		b.mu.Unlock()
+		readyAt = time.Now().Add(interval)
+		req.result <- BusResult{Response: resp, Err: err}
+	}
+}
+
+// wait 等待到 readyAt，期间请求被取消或调度器关闭时返回错误
+func (b *Bus) wait(ctx context.Context, readyAt time.Time) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	delay := time.Until(readyAt)
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-b.done:
+		return BusClosedError
+	}
+}
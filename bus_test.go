@@ -0,0 +1,185 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordConn 记录发出的报文和发送时间
+type recordConn struct {
+	net.Conn
+	mu     sync.Mutex
+	frames [][]byte
+	times  []time.Time
+}
+
+func (c *recordConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	c.frames = append(c.frames, append([]byte(nil), p...))
+	c.times = append(c.times, time.Now())
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+func newTestBus(t *testing.T, simulator *Simulator) (*Bus, *recordConn) {
+	t.Helper()
+	master, slave := net.Pipe()
+	t.Cleanup(func() {
+		master.Close()
+		slave.Close()
+	})
+	go simulator.Serve(slave)
+	conn := &recordConn{Conn: master}
+	bus := NewBus(conn, time.Second)
+	t.Cleanup(func() { bus.Close() })
+	return bus, conn
+}
+
+func TestBusPriority(t *testing.T) {
+	simulator := NewSimulator("000000013310")
+	simulator.SetFaults(50*time.Millisecond, 0, 0)
+	bus, conn := newTestBus(t, simulator)
+	bus.SetFrameGap(0)
+	bus.SetBroadcastSilence(30 * time.Millisecond)
+	meter := NewMeter("", "000000013310")
+	ctx := context.Background()
+
+	//第一帧占用总线期间提交的请求，广播先于抄读发送
+	addr, _ := BuildMasterReadMeterAddrRequest("")
+	first := bus.Submit(ctx, addr, PriorityPoll)
+	time.Sleep(10 * time.Millisecond)
+	read1, _ := meter.BuildMasterReadRequest([]byte{0x02, 0x01, 0x01, 0x00}, 0, nil)
+	read2, _ := meter.BuildMasterReadRequest([]byte{0x02, 0x01, 0x02, 0x00}, 0, nil)
+	broadcast, _ := BuildBroadcastTimeCalibration("", time.Now())
+	results := []<-chan BusResult{
+		first,
+		bus.Submit(ctx, read1, PriorityPoll),
+		bus.Submit(ctx, read2, PriorityPoll),
+		bus.Submit(ctx, broadcast, PriorityBroadcast),
+	}
+	for i, result := range results {
+		if r := <-result; r.Err != nil && !errors.Is(r.Err, MeterErrNoData) {
+			t.Fatalf("request %d: %v", i, r.Err)
+		}
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	expected := [][]byte{addr, broadcast, read1, read2}
+	for i, frame := range expected {
+		if !bytes.Equal(conn.frames[i], frame) {
+			t.Fatalf("frame %d: expected % X, got % X", i, frame, conn.frames[i])
+		}
+	}
+	if gap := conn.times[2].Sub(conn.times[1]); gap < 30*time.Millisecond {
+		t.Fatalf("broadcast silence not respected: %v", gap)
+	}
+}
+
+// TestBusBroadcastPriority 以低优先级提交的广播帧依然先于抄读发送
+func TestBusBroadcastPriority(t *testing.T) {
+	simulator := NewSimulator("000000013310")
+	simulator.SetFaults(50*time.Millisecond, 0, 0)
+	bus, conn := newTestBus(t, simulator)
+	bus.SetFrameGap(0)
+	bus.SetBroadcastSilence(0)
+	meter := NewMeter("", "000000013310")
+	ctx := context.Background()
+
+	addr, _ := BuildMasterReadMeterAddrRequest("")
+	first := bus.Submit(ctx, addr, PriorityPoll)
+	time.Sleep(10 * time.Millisecond)
+	read, _ := meter.BuildMasterReadRequest([]byte{0x02, 0x01, 0x01, 0x00}, 0, nil)
+	freeze, _ := BuildFreezeCommandRequest("", "", time.Now())
+	broadcast, _ := BuildBroadcastTimeCalibration("", time.Now())
+	results := []<-chan BusResult{
+		first,
+		bus.Submit(ctx, read, PriorityControl),
+		bus.Submit(ctx, freeze, PriorityPoll),
+		bus.Submit(ctx, broadcast, PriorityPoll),
+	}
+	for i, result := range results {
+		if r := <-result; r.Err != nil && !errors.Is(r.Err, MeterErrNoData) {
+			t.Fatalf("request %d: %v", i, r.Err)
+		}
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	expected := [][]byte{addr, freeze, broadcast, read}
+	for i, frame := range expected {
+		if !bytes.Equal(conn.frames[i], frame) {
+			t.Fatalf("frame %d: expected % X, got % X", i, frame, conn.frames[i])
+		}
+	}
+}
+
+func TestBusFrameGap(t *testing.T) {
+	simulator := NewSimulator("000000013310")
+	simulator.SetValue([]byte{0x02, 0x01, 0x01, 0x00}, []byte{0x19, 0x22})
+	bus, conn := newTestBus(t, simulator)
+	bus.SetFrameGap(20 * time.Millisecond)
+	meter := NewMeter("", "000000013310")
+
+	//多个协程同时抄读
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			frame, _ := meter.BuildMasterReadRequest([]byte{0x02, 0x01, 0x01, 0x00}, 0, nil)
+			resp, err := bus.Do(context.Background(), frame, PriorityPoll)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if !bytes.Equal(resp.Data[4:], []byte{0x19, 0x22}) {
+				t.Errorf("unexpected data % X", resp.Data)
+			}
+		}()
+	}
+	wg.Wait()
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	for i := 1; i < len(conn.times); i++ {
+		if gap := conn.times[i].Sub(conn.times[i-1]); gap < 20*time.Millisecond {
+			t.Fatalf("frame gap %v between frame %d and %d", gap, i-1, i)
+		}
+	}
+}
+
+func TestBusCancel(t *testing.T) {
+	simulator := NewSimulator("000000013310")
+	simulator.SetFaults(50*time.Millisecond, 0, 0)
+	bus, conn := newTestBus(t, simulator)
+	meter := NewMeter("", "000000013310")
+	addr, _ := BuildMasterReadMeterAddrRequest("")
+	first := bus.Submit(context.Background(), addr, PriorityPoll)
+	time.Sleep(10 * time.Millisecond)
+
+	//排队中取消的请求不会发出
+	ctx, cancel := context.WithCancel(context.Background())
+	read, _ := meter.BuildMasterReadRequest([]byte{0x02, 0x01, 0x01, 0x00}, 0, nil)
+	cancelled := bus.Submit(ctx, read, PriorityPoll)
+	cancel()
+	if r := <-cancelled; !errors.Is(r.Err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", r.Err)
+	}
+	if r := <-first; r.Err != nil {
+		t.Fatal(r.Err)
+	}
+	conn.mu.Lock()
+	sent := len(conn.frames)
+	conn.mu.Unlock()
+	if sent != 1 {
+		t.Fatalf("expected 1 frame sent, got %d", sent)
+	}
+
+	bus.Close()
+	if _, err := bus.Do(context.Background(), addr, PriorityPoll); !errors.Is(err, BusClosedError) {
+		t.Fatalf("expected closed error, got %v", err)
+	}
+}
@@ -0,0 +1,571 @@
+package go_dlt645_2007
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Access 数据项的读写权限
+type Access byte
+
+const (
+	AccessRead      Access = 0x01                     //只读
+	AccessWrite     Access = 0x02                     //只写
+	AccessReadWrite        = AccessRead | AccessWrite //读写
+)
+
+func (a Access) String() string {
+	switch a {
+	case AccessRead:
+		return "R"
+	case AccessWrite:
+		return "W"
+	case AccessReadWrite:
+		return "RW"
+	default:
+		return "-"
+	}
+}
+
+// DataIdentInfo 数据标识说明，来自 DL/T 645-2007 附录A
+type DataIdentInfo struct {
+	Ident    []byte //数据标识 DI3 DI2 DI1 DI0
+	Name     string //数据项名称
+	Format   string //数据格式，例如 XXX.X
+	Length   int    //单个数据项的字节数，数据块由若干个该长度的数据项组成，0 表示长度不固定
+	Unit     string //单位
+	Access   Access //读写权限
+	Signed   bool   //是否是带符号BCD，最高位为符号位
+	Bitfield bool   //是否是状态字、特征字、模式字等按位定义的数据，按十六进制解析
+}
+
+// IsBlock 是否是数据块标识
+func (d *DataIdentInfo) IsBlock() bool {
+	return d.Ident[2] == 0xFF || d.Ident[3] == 0xFF
+}
+
+// Parser 根据数据格式创建一个数据解析器，仅支持定长的数值格式，按位定义的数据使用 Decoder
+func (d *DataIdentInfo) Parser() (*MeterDataParser, error) {
+	if d.Bitfield {
+		return nil, fmt.Errorf("dlt645_2007 catalogue: %s is a bitfield", d.Name)
+	}
+	decimals, ok := numericFormat(d.Format)
+	if !ok || d.Length <= 0 {
+		return nil, fmt.Errorf("dlt645_2007 catalogue: format %s is not numeric", d.Format)
+	}
+	if d.Signed {
+		return NewSignedMeterDataParser(d.Length, nil, math.Pow10(-decimals), 0, d.Unit)
+	}
+	return NewMeterDataParser(d.Length, nil, math.Pow10(-decimals), 0, d.Unit)
+}
+
+// TimeParser 根据数据格式创建一个日期时间数据解析器，仅支持日期时间格式
+// loc 时区，nil 表示 time.Local
+func (d *DataIdentInfo) TimeParser(loc *time.Location) (*TimeDataParser, error) {
+	return NewTimeDataParser(d.Format, loc)
+}
+
+// Decoder 根据数据格式创建一个数据域解码器，支持数值、按位定义的数据、日期时间以及最大需量及发生时间格式，
+// 按位定义的数据解析为高字节在前的十六进制字符串，例如 000A，最大需量及发生时间解析为 {"value": 需量, "time": 发生时间}
+// loc 时区，nil 表示 time.Local
+func (d *DataIdentInfo) Decoder(loc *time.Location) (DataDecoder, error) {
+	if d.Bitfield {
+		return NewHexDataParser(d.Length)
+	}
+	if parser, err := d.Parser(); err == nil {
+		return parser, nil
+	}
+	if parser, err := d.TimeParser(loc); err == nil {
+		return parser, nil
+	}
+	if d.Format == formatDemandAt {
+		demand, err := NewMeterDataParser(3, nil, 0.0001, 0, d.Unit)
+		if err != nil {
+			return nil, err
+		}
+		at, err := NewTimeDataParser(FormatYYMMDDhhmm, loc)
+		if err != nil {
+			return nil, err
+		}
+		return NewRecordParser(RecordField{Name: "value", Decoder: demand}, RecordField{Name: "time", Decoder: at})
+	}
+	return nil, fmt.Errorf("dlt645_2007 catalogue: format %s is not supported", d.Format)
+}
+
+// numericFormat 判断是否是数值格式，返回小数位数
+func numericFormat(format string) (int, bool) {
+	if format == "" {
+		return 0, false
+	}
+	integer, fraction, _ := strings.Cut(format, ".")
+	for _, c := range integer + fraction {
+		if c != 'X' && c != 'N' {
+			return 0, false
+		}
+	}
+	return len(fraction), true
+}
+
+// LookupDataIdent 查询数据标识的说明
+// ident 数据标识 DI3 DI2 DI1 DI0
+func LookupDataIdent(ident []byte) (*DataIdentInfo, bool) {
+	if len(ident) != 4 {
+		return nil, false
+	}
+	var item *catalogueItem
+	if fixed, ok := catalogueItems[binary.BigEndian.Uint32(ident)]; ok {
+		item = &fixed
+	} else {
+		switch ident[0] {
+		case 0x00:
+			item = lookupEnergy(ident)
+		case 0x01:
+			item = lookupDemand(ident)
+		case 0x02:
+			item = lookupVariable(ident)
+		case 0x03:
+			item = lookupEvent(ident)
+		case 0x05:
+			item = lookupFreeze(ident)
+		case 0x06:
+			item = lookupLoadRecord(ident)
+		}
+	}
+	if item == nil {
+		return nil, false
+	}
+	return &DataIdentInfo{
+		Ident:    append([]byte(nil), ident...),
+		Name:     item.name,
+		Format:   item.format,
+		Length:   item.length,
+		Unit:     item.unit,
+		Access:   item.access,
+		Signed:   item.signed,
+		Bitfield: item.bitfield,
+	}, true
+}
+
+type catalogueItem struct {
+	name     string
+	format   string
+	length   int
+	unit     string
+	access   Access
+	signed   bool
+	bitfield bool
+}
+
+const (
+	formatEnergy    = "XXXXXX.XX"
+	formatDemand    = "XX.XXXX"
+	formatDemandAt  = "XX.XXXX,YYMMDDhhmm"
+	formatEventTime = "YYMMDDhhmmss"
+	formatRecord    = "记录"
+)
+
+// energyKinds 电能量和最大需量的 DI2
+var energyKinds = map[byte]struct {
+	name   string
+	unit   string
+	signed bool
+}{
+	0x00: {"组合有功", "kWh", true},
+	0x01: {"正向有功", "kWh", false},
+	0x02: {"反向有功", "kWh", false},
+	0x03: {"组合无功1", "kvarh", true},
+	0x04: {"组合无功2", "kvarh", true},
+	0x05: {"第一象限无功", "kvarh", false},
+	0x06: {"第二象限无功", "kvarh", false},
+	0x07: {"第三象限无功", "kvarh", false},
+	0x08: {"第四象限无功", "kvarh", false},
+	0x09: {"正向视在", "kVAh", false},
+	0x0A: {"反向视在", "kVAh", false},
+}
+
+// phaseEnergyKind 分相电能量的 DI2，A相从 15H 开始，B相 29H，C相 3DH
+func phaseEnergyKind(di2 byte) (string, string, bool, bool) {
+	for i, phase := range []string{"A相", "B相", "C相"} {
+		base := byte(0x15 + 0x14*i)
+		if di2 >= base && di2 <= base+0x09 {
+			kind := energyKinds[di2-base+0x01]
+			return phase + kind.name, kind.unit, kind.signed, true
+		}
+	}
+	return "", "", false, false
+}
+
+func settlementName(di0 byte) string {
+	switch di0 {
+	case 0x00:
+		return "(当前)"
+	case 0xFF:
+		return "(当前及上12结算日)"
+	default:
+		return fmt.Sprintf("(上%d结算日)", di0)
+	}
+}
+
+func tariffName(di1 byte) string {
+	switch di1 {
+	case 0x00:
+		return "总"
+	case 0xFF:
+		return "总及费率"
+	default:
+		return fmt.Sprintf("费率%d", di1)
+	}
+}
+
+func blockName(ident []byte) string {
+	if ident[2] == 0xFF || ident[3] == 0xFF {
+		return "数据块"
+	}
+	return ""
+}
+
+func energyKind(ident []byte) (string, string, bool, bool) {
+	if kind, ok := energyKinds[ident[1]]; ok {
+		return kind.name, kind.unit, kind.signed, true
+	}
+	return phaseEnergyKind(ident[1])
+}
+
+// lookupEnergy 电能量 00xxxxxx
+func lookupEnergy(ident []byte) *catalogueItem {
+	if ident[2] == 0xFF && ident[3] == 0xFF || ident[3] > 0x0C && ident[3] != 0xFF {
+		return nil
+	}
+	name, unit, signed, ok := energyKind(ident)
+	if !ok {
+		return nil
+	}
+	if ident[1] >= 0x15 && ident[2] != 0x00 || ident[2] > 0x3F && ident[2] != 0xFF {
+		return nil
+	}
+	return &catalogueItem{
+		name:   settlementName(ident[3]) + name + tariffName(ident[2]) + "电能" + blockName(ident),
+		format: formatEnergy, length: 4, unit: unit, access: AccessRead, signed: signed,
+	}
+}
+
+// lookupDemand 最大需量及发生时间 01xxxxxx
+func lookupDemand(ident []byte) *catalogueItem {
+	if ident[1] == 0x00 || ident[2] == 0xFF && ident[3] == 0xFF || ident[3] > 0x0C && ident[3] != 0xFF {
+		return nil
+	}
+	name, unit, _, ok := energyKind(ident)
+	if !ok {
+		return nil
+	}
+	if ident[1] >= 0x15 && ident[2] != 0x00 || ident[2] > 0x3F && ident[2] != 0xFF {
+		return nil
+	}
+	return &catalogueItem{
+		name:   settlementName(ident[3]) + name + tariffName(ident[2]) + "最大需量及发生时间" + blockName(ident),
+		format: formatDemandAt, length: 8, unit: strings.TrimSuffix(unit, "h"), access: AccessRead,
+	}
+}
+
+// variableKinds 变量 0201~0209，firstDI1 为 DI1 的最小取值
+var variableKinds = map[byte]struct {
+	name     string
+	format   string
+	length   int
+	unit     string
+	firstDI1 byte
+	signed   bool
+}{
+	0x01: {"电压", "XXX.X", 2, "V", 0x01, false},
+	0x02: {"电流", "XXX.XXX", 3, "A", 0x01, true},
+	0x03: {"瞬时有功功率", "XX.XXXX", 3, "kW", 0x00, true},
+	0x04: {"瞬时无功功率", "XX.XXXX", 3, "kvar", 0x00, true},
+	0x05: {"瞬时视在功率", "XX.XXXX", 3, "kVA", 0x00, true},
+	0x06: {"功率因数", "X.XXX", 2, "", 0x00, true},
+	0x07: {"相角", "XXX.X", 2, "°", 0x01, false},
+	0x08: {"电压波形失真度", "XX.XX", 2, "%", 0x01, false},
+	0x09: {"电流波形失真度", "XX.XX", 2, "%", 0x01, false},
+}
+
+var phaseNames = []string{"总", "A相", "B相", "C相"}
+
+// lookupVariable 变量 02xxxxxx
+func lookupVariable(ident []byte) *catalogueItem {
+	switch ident[1] {
+	case 0x0A, 0x0B: //谐波含量，DI1 为相别，DI0 为谐波次数
+		if ident[2] < 0x01 || ident[2] > 0x03 || ident[3] < 0x01 || ident[3] > 0x15 && ident[3] != 0xFF {
+			return nil
+		}
+		name := phaseNames[ident[2]] + "电压"
+		if ident[1] == 0x0B {
+			name = phaseNames[ident[2]] + "电流"
+		}
+		if ident[3] == 0xFF {
+			name += "谐波含量数据块"
+		} else {
+			name += fmt.Sprintf("%d次谐波含量", ident[3])
+		}
+		return &catalogueItem{name: name, format: "XX.XX", length: 2, unit: "%", access: AccessRead}
+	}
+	kind, ok := variableKinds[ident[1]]
+	if !ok || ident[3] != 0x00 {
+		return nil
+	}
+	if ident[2] == 0xFF {
+		return &catalogueItem{name: kind.name + "数据块", format: kind.format, length: kind.length, unit: kind.unit, access: AccessRead, signed: kind.signed}
+	}
+	if ident[2] < kind.firstDI1 || ident[2] > 0x03 {
+		return nil
+	}
+	return &catalogueItem{name: phaseNames[ident[2]] + kind.name, format: kind.format, length: kind.length, unit: kind.unit, access: AccessRead, signed: kind.signed}
+}
+
+// eventKinds 事件记录 03xxxxxx 中按相别统计次数和累计时间的事件
+var eventKinds = map[byte]string{
+	0x01: "失压", 0x02: "欠压", 0x03: "过压", 0x04: "断相",
+	0x07: "电压逆相序", 0x08: "电流逆相序", 0x09: "电压不平衡", 0x0A: "电流不平衡",
+	0x0B: "失流", 0x0C: "过流", 0x0D: "断流", 0x0E: "潮流反向", 0x0F: "过载",
+}
+
+// programEventKinds 编程类事件 0330xxxx，值为记录的字节数
+var programEventKinds = map[byte]struct {
+	name   string
+	length int
+}{
+	0x00: {"编程", 50},
+	0x01: {"电表清零", 106},
+	0x02: {"需量清零", 202},
+	0x03: {"事件清零", 14},
+	0x04: {"校时", 16},
+	0x05: {"时段表编程", 682},
+	0x06: {"时区表编程", 94},
+	0x0D: {"开表盖", 60},
+	0x0E: {"开端钮盒", 60},
+}
+
+// lookupEvent 事件记录 03xxxxxx
+func lookupEvent(ident []byte) *catalogueItem {
+	if name, ok := eventKinds[ident[1]]; ok {
+		if ident[2] == 0x00 && ident[3] == 0x00 {
+			return &catalogueItem{name: "A/B/C相" + name + "总次数及总累计时间", format: "XXXXXX", length: 3, unit: "", access: AccessRead}
+		}
+		if ident[2] >= 0x01 && ident[2] <= 0x03 && ident[3] >= 0x01 && ident[3] <= 0x0A {
+			return &catalogueItem{name: fmt.Sprintf("%s%s记录(上%d次)", phaseNames[ident[2]], name, ident[3]), format: formatRecord, unit: "", access: AccessRead}
+		}
+		return nil
+	}
+	switch ident[1] {
+	case 0x05, 0x06: //全失压、辅助电源失电
+		name := "全失压"
+		if ident[1] == 0x06 {
+			name = "辅助电源失电"
+		}
+		if ident[2] != 0x00 {
+			return nil
+		}
+		if ident[3] == 0x00 {
+			return &catalogueItem{name: name + "总次数及总累计时间", format: "XXXXXX", length: 3, access: AccessRead}
+		}
+		if ident[3] <= 0x0A {
+			return &catalogueItem{name: fmt.Sprintf("%s记录(上%d次)", name, ident[3]), format: formatRecord, access: AccessRead}
+		}
+	case 0x11: //掉电
+		if ident[2] != 0x00 {
+			return nil
+		}
+		if ident[3] == 0x00 {
+			return &catalogueItem{name: "掉电总次数", format: "XXXXXX", length: 3, access: AccessRead}
+		}
+		if ident[3] <= 0x0A {
+			return &catalogueItem{name: fmt.Sprintf("掉电发生时刻及结束时刻(上%d次)", ident[3]), format: formatEventTime, length: 6, access: AccessRead}
+		}
+	case 0x30: //编程类事件
+		kind, ok := programEventKinds[ident[2]]
+		if !ok {
+			return nil
+		}
+		if ident[3] == 0x00 {
+			return &catalogueItem{name: kind.name + "总次数", format: "XXXXXX", length: 3, access: AccessRead}
+		}
+		if ident[3] <= 0x0A {
+			return &catalogueItem{name: fmt.Sprintf("%s记录(上%d次)", kind.name, ident[3]), format: formatRecord, length: kind.length, access: AccessRead}
+		}
+	}
+	return nil
+}
+
+// freezeKinds 冻结数据 05xxxxxx 的 DI2，值为 DI0 的最大次数
+var freezeKinds = map[byte]struct {
+	name  string
+	times byte
+}{
+	0x00: {"定时冻结", 0x0C},
+	0x01: {"瞬时冻结", 0x03},
+	0x02: {"两套时区表切换", 0x02},
+	0x03: {"两套日时段表切换", 0x02},
+	0x04: {"整点冻结", 0xFE},
+	0x05: {"两套费率电价切换", 0x02},
+	0x06: {"日冻结", 0x3E},
+	0x07: {"两套阶梯切换", 0x02},
+}
+
+// freezeItems 冻结数据的 DI1
+var freezeItems = map[byte]catalogueItem{
+	0x00: {name: "时间", format: "YYMMDDhhmm", length: 5},
+	0x01: {name: "正向有功电能数据", format: formatEnergy, length: 4, unit: "kWh"},
+	0x02: {name: "反向有功电能数据", format: formatEnergy, length: 4, unit: "kWh"},
+	0x03: {name: "组合无功1电能数据", format: formatEnergy, length: 4, unit: "kvarh", signed: true},
+	0x04: {name: "组合无功2电能数据", format: formatEnergy, length: 4, unit: "kvarh", signed: true},
+	0x05: {name: "第一象限无功电能数据", format: formatEnergy, length: 4, unit: "kvarh"},
+	0x06: {name: "第二象限无功电能数据", format: formatEnergy, length: 4, unit: "kvarh"},
+	0x07: {name: "第三象限无功电能数据", format: formatEnergy, length: 4, unit: "kvarh"},
+	0x08: {name: "第四象限无功电能数据", format: formatEnergy, length: 4, unit: "kvarh"},
+	0x09: {name: "正向有功最大需量及发生时间数据", format: formatDemandAt, length: 8, unit: "kW"},
+	0x0A: {name: "反向有功最大需量及发生时间数据", format: formatDemandAt, length: 8, unit: "kW"},
+	0x10: {name: "变量数据", format: formatDemand, length: 3, unit: "kW", signed: true},
+}
+
+// lookupFreeze 冻结数据 05xxxxxx
+func lookupFreeze(ident []byte) *catalogueItem {
+	kind, ok := freezeKinds[ident[1]]
+	if !ok || ident[3] == 0x00 || ident[3] > kind.times && ident[3] != 0xFF || ident[2] == 0xFF && ident[3] == 0xFF {
+		return nil
+	}
+	times := fmt.Sprintf("(上%d次)", ident[3])
+	if ident[3] == 0xFF {
+		times = ""
+	}
+	if ident[2] == 0xFF {
+		return &catalogueItem{name: times + kind.name + "数据块", format: formatRecord, access: AccessRead}
+	}
+	item, ok := freezeItems[ident[2]]
+	if !ok || ident[1] == 0x04 && ident[2] > 0x02 {
+		return nil
+	}
+	item.name = times + kind.name + item.name
+	item.access = AccessRead
+	return &item
+}
+
+// lookupLoadRecord 负荷记录 06xxxxxx
+func lookupLoadRecord(ident []byte) *catalogueItem {
+	if ident[2] != 0x00 || ident[3] > 0x02 || ident[1] > 0x06 {
+		return nil
+	}
+	name := "所有类别负荷"
+	if ident[1] > 0x00 {
+		name = fmt.Sprintf("第%d类负荷", ident[1])
+	}
+	return &catalogueItem{name: name + []string{"最早记录块", "给定时间记录块", "最近一个记录块"}[ident[3]], format: "负荷记录", access: AccessRead}
+}
+
+// catalogueItems 固定的数据标识
+var catalogueItems = map[uint32]catalogueItem{
+	/*---------------变量------------------*/
+	0x02800001: {name: "零线电流", format: "XXX.XXX", length: 3, unit: "A", access: AccessRead},
+	0x02800002: {name: "电网频率", format: "XX.XX", length: 2, unit: "Hz", access: AccessRead},
+	0x02800003: {name: "一分钟有功总平均功率", format: "XX.XXXX", length: 3, unit: "kW", access: AccessRead, signed: true},
+	0x02800004: {name: "当前有功需量", format: "XX.XXXX", length: 3, unit: "kW", access: AccessRead, signed: true},
+	0x02800005: {name: "当前无功需量", format: "XX.XXXX", length: 3, unit: "kvar", access: AccessRead, signed: true},
+	0x02800006: {name: "当前视在需量", format: "XX.XXXX", length: 3, unit: "kVA", access: AccessRead, signed: true},
+	0x02800007: {name: "表内温度", format: "XXX.X", length: 2, unit: "℃", access: AccessRead, signed: true},
+	0x02800008: {name: "时钟电池电压(内部)", format: "XX.XX", length: 2, unit: "V", access: AccessRead},
+	0x02800009: {name: "停电抄表电池电压(外部)", format: "XX.XX", length: 2, unit: "V", access: AccessRead},
+	0x0280000A: {name: "内部电池工作时间", format: "XXXXXXXX", length: 4, unit: "min", access: AccessRead},
+	0x0280000B: {name: "当前阶梯电价", format: "XXXX.XXXX", length: 4, unit: "元/kWh", access: AccessRead},
+	/*---------------参变量------------------*/
+	0x04000101: {name: "日期及星期", format: "YYMMDDWW", length: 4, access: AccessReadWrite},
+	0x04000102: {name: "时间", format: "hhmmss", length: 3, access: AccessReadWrite},
+	0x04000103: {name: "最大需量周期", format: "NN", length: 1, unit: "min", access: AccessReadWrite},
+	0x04000104: {name: "滑差时间", format: "NN", length: 1, unit: "min", access: AccessReadWrite},
+	0x04000105: {name: "校表脉冲宽度", format: "XXXX", length: 2, unit: "ms", access: AccessReadWrite},
+	0x04000106: {name: "两套时区表切换时间", format: "YYMMDDhhmm", length: 5, access: AccessReadWrite},
+	0x04000107: {name: "两套日时段表切换时间", format: "YYMMDDhhmm", length: 5, access: AccessReadWrite},
+	0x04000201: {name: "年时区数", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000202: {name: "日时段表数", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000203: {name: "日时段数(每日切换数)", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000204: {name: "费率数", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000205: {name: "公共假日数", format: "NNNN", length: 2, access: AccessReadWrite},
+	0x04000206: {name: "谐波分析次数", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000401: {name: "通信地址", format: "NNNNNNNNNNNN", length: 6, access: AccessReadWrite},
+	0x04000402: {name: "表号", format: "NNNNNNNNNNNN", length: 6, access: AccessReadWrite},
+	0x04000403: {name: "资产管理编码", format: "ASCII", length: 32, access: AccessReadWrite},
+	0x04000404: {name: "额定电压", format: "ASCII", length: 6, access: AccessReadWrite},
+	0x04000405: {name: "额定电流/基本电流", format: "ASCII", length: 6, access: AccessReadWrite},
+	0x04000406: {name: "最大电流", format: "ASCII", length: 6, access: AccessReadWrite},
+	0x04000407: {name: "有功准确度等级", format: "ASCII", length: 4, access: AccessReadWrite},
+	0x04000408: {name: "无功准确度等级", format: "ASCII", length: 4, access: AccessReadWrite},
+	0x04000409: {name: "电表有功常数", format: "XXXXXX", length: 3, unit: "imp/kWh", access: AccessReadWrite},
+	0x0400040A: {name: "电表无功常数", format: "XXXXXX", length: 3, unit: "imp/kvarh", access: AccessReadWrite},
+	0x0400040B: {name: "电表型号", format: "ASCII", length: 10, access: AccessReadWrite},
+	0x0400040C: {name: "生产日期", format: "ASCII", length: 10, access: AccessReadWrite},
+	0x0400040D: {name: "协议版本号", format: "ASCII", length: 16, access: AccessReadWrite},
+	0x04000501: {name: "电表运行状态字1", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000502: {name: "电表运行状态字2", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000503: {name: "电表运行状态字3", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000504: {name: "电表运行状态字4", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000505: {name: "电表运行状态字5", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000506: {name: "电表运行状态字6", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000507: {name: "电表运行状态字7", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x040005FF: {name: "电表运行状态字数据块", format: "XXXX", length: 2, access: AccessRead, bitfield: true},
+	0x04000601: {name: "有功组合方式特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000602: {name: "无功组合方式1特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000603: {name: "无功组合方式2特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000701: {name: "调制型红外光口通信速率特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000702: {name: "接触式红外光口通信速率特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000703: {name: "通信口1通信速率特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000704: {name: "通信口2通信速率特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000705: {name: "通信口3通信速率特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000801: {name: "周休日特征字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000802: {name: "周休日采用的日时段表号", format: "NN", length: 1, access: AccessReadWrite},
+	0x04000901: {name: "负荷记录模式字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000902: {name: "冻结数据模式字", format: "NN", length: 1, access: AccessReadWrite, bitfield: true},
+	0x04000A01: {name: "负荷记录起始时间", format: "MMDDhhmm", length: 4, access: AccessReadWrite},
+	0x04000A02: {name: "第1类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000A03: {name: "第2类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000A04: {name: "第3类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000A05: {name: "第4类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000A06: {name: "第5类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000A07: {name: "第6类负荷记录间隔时间", format: "NNNN", length: 2, unit: "min", access: AccessReadWrite},
+	0x04000B01: {name: "每月第1结算日", format: "DDhh", length: 2, access: AccessReadWrite},
+	0x04000B02: {name: "每月第2结算日", format: "DDhh", length: 2, access: AccessReadWrite},
+	0x04000B03: {name: "每月第3结算日", format: "DDhh", length: 2, access: AccessReadWrite},
+	0x04000C01: {name: "0级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C02: {name: "1级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C03: {name: "2级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C04: {name: "3级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C05: {name: "4级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C06: {name: "5级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C07: {name: "6级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C08: {name: "7级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C09: {name: "8级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000C0A: {name: "9级密码", format: "NNNNNNNN", length: 4, access: AccessWrite},
+	0x04000E01: {name: "正向有功功率上限值", format: "XX.XXXX", length: 3, unit: "kW", access: AccessReadWrite},
+	0x04000E02: {name: "反向有功功率上限值", format: "XX.XXXX", length: 3, unit: "kW", access: AccessReadWrite},
+	0x04000E03: {name: "电压上限值", format: "XXX.X", length: 2, unit: "V", access: AccessReadWrite},
+	0x04000E04: {name: "电压下限值", format: "XXX.X", length: 2, unit: "V", access: AccessReadWrite},
+	0x04010000: {name: "第一套时区表数据", format: "MMDDNN", length: 3, access: AccessReadWrite},
+	0x04010001: {name: "第一套第1日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010002: {name: "第一套第2日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010003: {name: "第一套第3日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010004: {name: "第一套第4日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010005: {name: "第一套第5日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010006: {name: "第一套第6日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010007: {name: "第一套第7日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04010008: {name: "第一套第8日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020000: {name: "第二套时区表数据", format: "MMDDNN", length: 3, access: AccessReadWrite},
+	0x04020001: {name: "第二套第1日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020002: {name: "第二套第2日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020003: {name: "第二套第3日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020004: {name: "第二套第4日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020005: {name: "第二套第5日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020006: {name: "第二套第6日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020007: {name: "第二套第7日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04020008: {name: "第二套第8日时段表数据", format: "hhmmNN", length: 3, access: AccessReadWrite},
+	0x04800001: {name: "厂家软件版本号", format: "ASCII", length: 32, access: AccessRead},
+	0x04800002: {name: "厂家硬件版本号", format: "ASCII", length: 32, access: AccessRead},
+	0x04800003: {name: "厂家编号", format: "ASCII", length: 32, access: AccessRead},
+}
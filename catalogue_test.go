@@ -0,0 +1,71 @@
+package go_dlt645_2007
+
+import "testing"
+
+func TestLookupDataIdent(t *testing.T) {
+	cases := []struct {
+		ident  []byte
+		name   string
+		length int
+		unit   string
+	}{
+		{[]byte{0x00, 0x01, 0x00, 0x00}, "(当前)正向有功总电能", 4, "kWh"},
+		{[]byte{0x01, 0x01, 0x02, 0x03}, "(上3结算日)正向有功费率2最大需量及发生时间", 8, "kW"},
+		{[]byte{0x02, 0x01, 0x01, 0x00}, "A相电压", 2, "V"},
+		{[]byte{0x02, 0x80, 0x00, 0x02}, "电网频率", 2, "Hz"},
+		{[]byte{0x04, 0x00, 0x01, 0x01}, "日期及星期", 4, ""},
+		{[]byte{0x05, 0x06, 0x01, 0x03}, "(上3次)日冻结正向有功电能数据", 4, "kWh"},
+	}
+	for _, c := range cases {
+		info, ok := LookupDataIdent(c.ident)
+		if !ok {
+			t.Fatalf("%X: not found", c.ident)
+		}
+		if info.Name != c.name || info.Length != c.length || info.Unit != c.unit {
+			t.Fatalf("%X: got %s/%d/%s", c.ident, info.Name, info.Length, info.Unit)
+		}
+	}
+	if _, ok := LookupDataIdent([]byte{0x00, 0x00, 0xFF, 0xFF}); ok {
+		t.Fatal("DI1 and DI0 can not both be FF")
+	}
+}
+
+func TestMeterDataCodecCatalogue(t *testing.T) {
+	codec := NewMeterDataCodec(nil)
+	codec.RegisterCatalogue()
+	parser, ok := codec.obtainParser(reverseBytes([]byte{0x02, 0x01, 0x01, 0x00}))
+	if !ok {
+		t.Fatal("parser not registered")
+	}
+	value, err := parser.Decode([]byte{0x19, 0x22})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.(float64) != 2219*0.1 {
+		t.Fatalf("unexpected value %v", value)
+	}
+}
+
+func TestMeterDataCodecCatalogueBitfield(t *testing.T) {
+	codec := NewMeterDataCodec(nil)
+	codec.RegisterCatalogue()
+	//电表运行状态字1 为 000A，不是合法的BCD码
+	value, err := codec.decode([]byte{0x04, 0x00, 0x05, 0x01}, []byte{0x0A, 0x00})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value != "000A" {
+		t.Fatalf("unexpected value %v", value)
+	}
+	value, err = codec.decode([]byte{0x04, 0x00, 0x05, 0xFF}, []byte{0x0A, 0x00, 0xF0, 0xBC})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := value.([]string); len(values) != 2 || values[0] != "000A" || values[1] != "BCF0" {
+		t.Fatalf("unexpected value %v", value)
+	}
+	info, _ := LookupDataIdent([]byte{0x04, 0x00, 0x06, 0x01})
+	if _, err = info.Parser(); err == nil {
+		t.Fatal("feature word must not use the bcd parser")
+	}
+}
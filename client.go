@@ -0,0 +1,594 @@
+package go_dlt645_2007
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseTimeoutError 等待应答超时
+var ResponseTimeoutError = errors.New("dlt645_2007 client: response timeout")
+
+// SeqError 后续帧的帧序号不连续
+var SeqError = errors.New("dlt645_2007 client: follow-up frame seq error")
+
+// ResponseMismatchError 应答与请求不匹配
+type ResponseMismatchError struct {
+	Address     string //请求的地址
+	ControlChar byte   //请求的控制码
+	Response    *MeterDlt645Protocol
+}
+
+func (e *ResponseMismatchError) Error() string {
+	return fmt.Sprintf("dlt645_2007 client: response mismatch, request %s/%02X, response %s/%02X",
+		e.Address, e.ControlChar, e.Response.Address, e.Response.ControlChar)
+}
+
+// AbnormalResponseError 从站异常应答
+type AbnormalResponseError struct {
+	ControlChar byte       //应答的控制码
+	ErrCode     MeterError //错误信息字
+}
+
+func (e *AbnormalResponseError) Error() string {
+	return fmt.Sprintf("dlt645_2007 client: abnormal response %02X, error code %02X: %s", e.ControlChar, byte(e.ErrCode), e.ErrCode.describe())
+}
+
+// Unwrap 返回错误信息字，可以使用 errors.Is(err, MeterErrNoData) 判断错误类型
+func (e *AbnormalResponseError) Unwrap() error {
+	return e.ErrCode
+}
+
+// readDeadliner 支持设置读超时的传输层，例如 net.Conn 和 *os.File
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+// NewClient 创建一个主站客户端
+// meter 表计
+// transport 传输层，例如串口、pty 或 net.Conn；实现了 SetReadDeadline 的传输层才支持超时和取消
+// timeout 等待应答的超时时间，0 表示一直等待
+func NewClient(meter *Meter, transport io.ReadWriter, timeout time.Duration) *Client {
+	return &Client{meter: meter, transport: transport, reader: bufio.NewReader(transport), timeout: timeout, codec: NewMeterDataCodec(nil), sem: make(chan struct{}, 1)}
+}
+
+// Client 主站客户端，发送请求帧并等待匹配的应答帧
+type Client struct {
+	meter     *Meter
+	transport io.ReadWriter
+	reader    *bufio.Reader
+	timeout   time.Duration
+	codec     *MeterDataCodec
+	codecMu   sync.Mutex    //解析器会修改注册表和解析状态，并发的 ReadAll 共用同一个 codec
+	sem       chan struct{} //请求锁，同一时间只有一个请求使用传输层，等待时可以被 ctx 取消
+	dirty     bool          //上一次请求没有收到完整的应答，迟到的应答可能还在传输层中
+}
+
+// Register 注册数据解析器，ReadAll 使用它解析合并后的数据域
+// ident 数据标识
+// parser 数据解析器
+func (c *Client) Register(ident []byte, parser *MeterDataParser) {
+	c.codecMu.Lock()
+	defer c.codecMu.Unlock()
+	c.codec.Register(ident, parser)
+}
+
+// RegisterDecoder 注册数据域解码器，ReadAll 使用它解析合并后的数据域
+// ident 数据标识
+// decoder 数据域解码器
+func (c *Client) RegisterDecoder(ident []byte, decoder DataDecoder) {
+	c.codecMu.Lock()
+	defer c.codecMu.Unlock()
+	c.codec.RegisterDecoder(ident, decoder)
+}
+
+// RegisterCatalogue 启用数据标识目录，ReadAll 对未注册的数据标识按目录中的数据格式解析
+func (c *Client) RegisterCatalogue() {
+	c.codecMu.Lock()
+	defer c.codecMu.Unlock()
+	c.codec.RegisterCatalogue()
+}
+
+// Request 发送一帧报文并等待匹配的应答，广播帧不等待应答，返回 nil
+// ctx 上下文，取消时中止等待其他请求完成或等待应答
+// frame 由 Build* 系列函数创建的请求帧
+func (c *Client) Request(ctx context.Context, frame []byte) (*MeterDlt645Protocol, error) {
+	if err := c.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer c.unlock()
+	return c.request(ctx, frame)
+}
+
+// lock 获取请求锁，ctx 取消时放弃等待并返回 ctx.Err()
+func (c *Client) lock(ctx context.Context) error {
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// unlock 释放请求锁
+func (c *Client) unlock() {
+	<-c.sem
+}
+
+// request 发送一帧报文并等待匹配的应答，调用者必须持有请求锁
+func (c *Client) request(ctx context.Context, frame []byte) (*MeterDlt645Protocol, error) {
+	req := &MeterDlt645Protocol{}
+	if err := req.Decode(frame); err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	//读超时和取消在丢弃残留数据之前生效，传输层建立连接时也会中止
+	deadline := time.Time{}
+	ctxDeadline := false //读超时是否为 ctx 的截止时间
+	if deadliner, ok := c.transport.(readDeadliner); ok {
+		if c.timeout > 0 {
+			deadline = time.Now().Add(c.timeout)
+		}
+		if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+			deadline, ctxDeadline = d, true
+		}
+		if err := deadliner.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer deadliner.SetReadDeadline(time.Time{})
+		//取消时把读超时设置为过去的时间，中止正在进行的读取
+		aborted := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			defer close(aborted)
+			deadliner.SetReadDeadline(time.Unix(1, 0))
+		})
+		defer func() {
+			if !stop() {
+				<-aborted
+			}
+		}()
+	}
+	//丢弃上一次请求残留的数据
+	if err := c.drain(deadline); err != nil {
+		return nil, requestError(ctx, err, ctxDeadline)
+	}
+	//丢弃残留数据时恢复的读超时可能覆盖了取消
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.reader.Reset(c.transport)
+	if _, err := c.transport.Write(frame); err != nil {
+		return nil, requestError(ctx, err, ctxDeadline)
+	}
+	if req.Address == BroadcastAddress {
+		return nil, nil
+	}
+	for {
+		resp := &MeterDlt645Protocol{}
+		if err := resp.DecodeByBuf(c.reader); err != nil {
+			//迟到的应答在下一次请求前丢弃
+			c.dirty = true
+			return nil, requestError(ctx, err, ctxDeadline)
+		}
+		//方向位为0的是主站发出的帧(例如485回显)，跳过
+		if resp.ControlChar&0x80 == 0 {
+			continue
+		}
+		if !matchAddress(req.Address, resp.Address) || resp.ControlChar&0x1F != req.ControlChar&0x1F {
+			return nil, &ResponseMismatchError{Address: req.Address, ControlChar: req.ControlChar, Response: resp}
+		}
+		if resp.ControlChar&0x40 != 0 {
+			return resp, &AbnormalResponseError{ControlChar: resp.ControlChar, ErrCode: obtainErrCode(resp.Data)}
+		}
+		return resp, nil
+	}
+}
+
+// requestError 转换读写时的错误：ctx 被取消或到达截止时间时返回 ctx 的错误，读超时返回 ResponseTimeoutError
+// ctxDeadline 读超时是否为 ctx 的截止时间
+func requestError(ctx context.Context, err error, ctxDeadline bool) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	if isTimeout(err) {
+		if ctxDeadline {
+			return context.DeadlineExceeded
+		}
+		return ResponseTimeoutError
+	}
+	return err
+}
+
+// drain 发送请求前丢弃传输层中残留的数据：传输层实现了 Drain 时直接调用，
+// 否则在上一次请求被取消或超时后，丢弃传输层中已经收到的数据，等待时间不超过本次请求的读超时
+// deadline 本次请求的读超时，丢弃后恢复
+func (c *Client) drain(deadline time.Time) error {
+	if d, ok := c.transport.(drainer); ok {
+		return d.Drain()
+	}
+	deadliner, ok := c.transport.(readDeadliner)
+	if !ok || !c.dirty {
+		return nil
+	}
+	c.dirty = false
+	drainDeadline := time.Now().Add(drainTimeout)
+	if !deadline.IsZero() && deadline.Before(drainDeadline) {
+		drainDeadline = deadline
+	}
+	if err := deadliner.SetReadDeadline(drainDeadline); err != nil {
+		return err
+	}
+	defer deadliner.SetReadDeadline(deadline)
+	buf := make([]byte, drainBufferSize)
+	for {
+		if _, err := c.transport.Read(buf); err != nil {
+			if isTimeout(err) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Read 读数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识
+func (c *Client) Read(ctx context.Context, ident []byte) (*MeterDlt645Protocol, error) {
+	frame, err := c.meter.BuildMasterReadRequest(ident, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Request(ctx, frame)
+}
+
+// ReadNext 读后续数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识
+// seq 帧序号
+func (c *Client) ReadNext(ctx context.Context, ident []byte, seq byte) (*MeterDlt645Protocol, error) {
+	frame, err := c.meter.BuildMasterReadNextDataRequest(ident, seq)
+	if err != nil {
+		return nil, err
+	}
+	return c.Request(ctx, frame)
+}
+
+// ReadAll 读数据并自动读取全部后续帧，合并后的数据域使用注册的解析器解析一次，
+// 未注册解析器时返回合并后的原始数据域 []byte
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识
+func (c *Client) ReadAll(ctx context.Context, ident []byte) (any, error) {
+	data, err := c.readAll(ctx, ident, 0, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.codecMu.Lock()
+	defer c.codecMu.Unlock()
+	return c.codec.decode(ident, data)
+}
+
+// readAll 发送读数据请求，有后续帧时按帧序号依次读取，返回合并后的数据(不含数据标识和帧序号)
+// ctx 上下文，取消时中止等待应答
+func (c *Client) readAll(ctx context.Context, ident []byte, block byte, ts *time.Time) ([]byte, error) {
+	return readAll(ctx, c.meter, c.Request, ident, block, ts)
+}
+
+// requestFunc 发送一帧请求并等待应答，例如 Client.Request
+type requestFunc func(ctx context.Context, frame []byte) (*MeterDlt645Protocol, error)
+
+// readAll 通过 request 发送读数据请求，有后续帧时按帧序号依次读取，返回合并后的数据(不含数据标识和帧序号)
+func readAll(ctx context.Context, meter *Meter, request requestFunc, ident []byte, block byte, ts *time.Time) ([]byte, error) {
+	frame, err := meter.BuildMasterReadRequest(ident, block, ts)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := request(ctx, frame)
+	if err != nil {
+		return nil, err
+	}
+	wireIdent := reverseBytes(ident)
+	if len(resp.Data) < 4 || !bytes.Equal(resp.Data[:4], wireIdent) {
+		return nil, DataDomainError
+	}
+	data := append([]byte(nil), resp.Data[4:]...)
+	hasNext := resp.ControlChar == RespondingNormallyHasNext
+	for seq := byte(1); hasNext; seq++ {
+		if seq == 0 {
+			return nil, SeqError
+		}
+		if frame, err = meter.BuildMasterReadNextDataRequest(ident, seq); err != nil {
+			return nil, err
+		}
+		if resp, err = request(ctx, frame); err != nil {
+			return nil, err
+		}
+		if len(resp.Data) < 5 || !bytes.Equal(resp.Data[:4], wireIdent) {
+			return nil, DataDomainError
+		}
+		if resp.Data[len(resp.Data)-1] != seq {
+			return nil, SeqError
+		}
+		data = append(data, resp.Data[4:len(resp.Data)-1]...)
+		hasNext = resp.ControlChar == NextRespondingNormallyHasNext
+	}
+	return data, nil
+}
+
+// Set 写数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识
+// pwd 密码
+// operatorCode 操作者代码
+// value 设定值
+// valueLength 数据长度
+func (c *Client) Set(ctx context.Context, ident []byte, pwd Password, operatorCode []byte, value interface{}, valueLength byte) error {
+	frame, err := c.meter.BuildMasterSetRequest(ident, pwd, operatorCode, value, valueLength)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// ReadAddress 读通信地址，总线上只能有一块电表
+// ctx 上下文，取消时中止等待应答
+func (c *Client) ReadAddress(ctx context.Context) (string, error) {
+	frame, err := BuildMasterReadMeterAddrRequest(c.meter.prefix)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Request(ctx, frame)
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Data) < 6 {
+		return "", DataDomainError
+	}
+	return resp.Address, nil
+}
+
+// SetAddress 设置通信地址，仅支持点对点通信
+// ctx 上下文，取消时中止等待应答
+// address 新的通信地址
+func (c *Client) SetAddress(ctx context.Context, address string) error {
+	frame, err := BuildMasterSetMeterAddrRequest(c.meter.prefix, address)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// Freeze 冻结命令，表计地址为广播地址时不等待应答
+// ctx 上下文，取消时中止等待应答
+// ti 冻结时间
+func (c *Client) Freeze(ctx context.Context, ti time.Time) error {
+	frame, err := BuildFreezeCommandRequest(c.meter.prefix, c.meter.address, ti)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// BroadcastTime 广播校时，不等待应答
+// ctx 上下文，取消时中止等待应答
+// ti 需要设置的时间
+func (c *Client) BroadcastTime(ctx context.Context, ti time.Time) error {
+	frame, err := BuildBroadcastTimeCalibration(c.meter.prefix, ti)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// RelayControl 跳合闸、报警、保电
+// ctx 上下文，取消时中止等待应答
+// pwd 密码
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+func (c *Client) RelayControl(ctx context.Context, pwd Password, operatorCode []byte, cmd RelayCommand, deadline time.Time) error {
+	frame, err := BuildRelayControlRequest(c.meter.prefix, c.meter.address, pwd, operatorCode, cmd, deadline)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// DemandClear 最大需量清零
+// ctx 上下文，取消时中止等待应答
+// pwd 密码
+// operatorCode 操作者代码
+func (c *Client) DemandClear(ctx context.Context, pwd Password, operatorCode []byte) error {
+	frame, err := BuildDemandClearRequest(c.meter.prefix, c.meter.address, pwd, operatorCode)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// MeterClear 电表清零
+// ctx 上下文，取消时中止等待应答
+// pwd 密码
+// operatorCode 操作者代码
+func (c *Client) MeterClear(ctx context.Context, pwd Password, operatorCode []byte) error {
+	frame, err := BuildMeterClearRequest(c.meter.prefix, c.meter.address, pwd, operatorCode)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// EventClear 事件清零
+// ctx 上下文，取消时中止等待应答
+// ident 事件记录的数据标识，FFFFFFFF 表示清全部事件
+// pwd 密码
+// operatorCode 操作者代码
+func (c *Client) EventClear(ctx context.Context, ident []byte, pwd Password, operatorCode []byte) error {
+	frame, err := BuildEventClearRequest(c.meter.prefix, c.meter.address, ident, pwd, operatorCode)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// ChangePassword 修改密码，返回表计应答的新密码
+// ctx 上下文，取消时中止等待应答
+// old 原密码
+// new 新密码
+func (c *Client) ChangePassword(ctx context.Context, old, new Password) (Password, error) {
+	frame, err := BuildPasswordChangeRequest(c.meter.prefix, c.meter.address, old, new)
+	if err != nil {
+		return Password{}, err
+	}
+	resp, err := c.Request(ctx, frame)
+	if err != nil {
+		return Password{}, err
+	}
+	return PasswordFromBytes(resp.Data)
+}
+
+// SetMultiFunctionOutput 多功能端子输出控制
+// ctx 上下文，取消时中止等待应答
+// output 输出信号类别
+func (c *Client) SetMultiFunctionOutput(ctx context.Context, output MultiFunctionOutput) error {
+	frame, err := BuildMultiOutputRequest(c.meter.prefix, c.meter.address, output)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// Read1997 按 DL/T 645-1997 读数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识，DI1 在前
+func (c *Client) Read1997(ctx context.Context, ident []byte) (*MeterDlt645Protocol, error) {
+	frame, err := c.meter.BuildMasterReadRequest1997(ident)
+	if err != nil {
+		return nil, err
+	}
+	return c.Request(ctx, frame)
+}
+
+// ReadNext1997 按 DL/T 645-1997 读后续数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识，DI1 在前
+func (c *Client) ReadNext1997(ctx context.Context, ident []byte) (*MeterDlt645Protocol, error) {
+	frame, err := c.meter.BuildMasterReadNextRequest1997(ident)
+	if err != nil {
+		return nil, err
+	}
+	return c.Request(ctx, frame)
+}
+
+// Set1997 按 DL/T 645-1997 写数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识，DI1 在前
+// pwd 密码
+// value 设定值
+// valueLength 数据长度
+func (c *Client) Set1997(ctx context.Context, ident []byte, pwd Password, value interface{}, valueLength byte) error {
+	frame, err := c.meter.BuildMasterSetRequest1997(ident, pwd, value, valueLength)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// detectTimeout 客户端没有设置超时时间时，DetectProtocol 每次探测等待应答的时间
+var detectTimeout = 2 * time.Second
+
+// DetectProtocol 检测表计的规约版本：先按 2007 读正向有功总电能(00010000)，
+// 没有收到数据标识相同的正常应答时再按 1997 读正向有功总电能(9010)，异常应答不能确定版本，按没有应答处理；
+// 每次探测最多等待客户端的超时时间，客户端没有设置超时时间时最多等待 2 秒
+// ctx 上下文，取消时中止等待应答
+func (c *Client) DetectProtocol(ctx context.Context) (ProtocolVersion, error) {
+	supported, err := c.probe(ctx, []byte{0x00, 0x01, 0x00, 0x00}, c.Read)
+	if err != nil {
+		return 0, err
+	}
+	if supported {
+		return Version2007, nil
+	}
+	supported, err = c.probe(ctx, []byte{0x90, 0x10}, c.Read1997)
+	if err != nil {
+		return 0, err
+	}
+	if supported {
+		return Version1997, nil
+	}
+	return 0, ResponseTimeoutError
+}
+
+// probe 读一次数据进行探测，表计以数据标识相同的正常应答时返回 true，没有应答、异常应答或应答不匹配时返回 false，
+// 其他错误(如传输层错误、ctx 被取消)原样返回
+// ident 数据标识
+// read 对应版本的读数据方法
+func (c *Client) probe(ctx context.Context, ident []byte, read func(context.Context, []byte) (*MeterDlt645Protocol, error)) (bool, error) {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = detectTimeout
+	}
+	probeCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := read(probeCtx, ident)
+	var abnormal *AbnormalResponseError
+	var mismatch *ResponseMismatchError
+	switch {
+	case err == nil:
+		return len(resp.Data) >= len(ident) && bytes.Equal(resp.Data[:len(ident)], reverseBytes(ident)), nil
+	case errors.As(err, &abnormal), errors.Is(err, ResponseTimeoutError), errors.As(err, &mismatch):
+		return false, nil
+	case errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil:
+		//只是探测超时
+		return false, nil
+	}
+	return false, err
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// matchAddress 判断应答地址是否与请求地址匹配，请求地址中的AA为通配
+func matchAddress(reqAddr, respAddr string) bool {
+	reqAddr = strings.ToLower(reqAddr)
+	respAddr = strings.ToLower(respAddr)
+	for len(reqAddr) < 12 {
+		reqAddr = "0" + reqAddr
+	}
+	if len(respAddr) != 12 || len(reqAddr) != 12 {
+		return false
+	}
+	for i := 0; i < 12; i += 2 {
+		if reqAddr[i:i+2] == strings.ToLower(substitute) {
+			continue
+		}
+		if reqAddr[i:i+2] != respAddr[i:i+2] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,293 @@
+package go_dlt645_2007
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// answerOnce 模拟表计，读取一帧请求后用 reply 生成应答
+func answerOnce(t *testing.T, conn net.Conn, reply func(req *MeterDlt645Protocol) []byte) {
+	t.Helper()
+	go func() {
+		req := &MeterDlt645Protocol{}
+		if err := req.DecodeByBuf(bufio.NewReader(conn)); err != nil {
+			return
+		}
+		if frame := reply(req); frame != nil {
+			_, _ = conn.Write(frame)
+		}
+	}()
+}
+
+func TestClientRead(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("FEFEFEFE", "000000013310"), master, time.Second)
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	answerOnce(t, slave, func(req *MeterDlt645Protocol) []byte {
+		frame, _ := BuildMasterReadResponse[uint64]("", req.Address, ident, &MeterData[uint64]{Value: 2219, Length: 2}, false)
+		return frame
+	})
+	resp, err := client.Read(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ControlChar != RespondingNormallyNoNext || resp.Address != "000000013310" {
+		t.Fatalf("unexpected response %02X %s", resp.ControlChar, resp.Address)
+	}
+}
+
+func TestClientMismatch(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	answerOnce(t, slave, func(req *MeterDlt645Protocol) []byte {
+		frame, _ := BuildMeterSetResponse("", "000000013311")
+		return frame
+	})
+	_, err := client.Read(context.Background(), []byte{0x02, 0x01, 0x01, 0x00})
+	var mismatch *ResponseMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("expected mismatch error, got %v", err)
+	}
+}
+
+func TestClientAbnormal(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	answerOnce(t, slave, func(req *MeterDlt645Protocol) []byte {
+		frame, _ := BuildMeterAbnormalResponse("", req.Address, 0x02)
+		return frame
+	})
+	_, err := client.Read(context.Background(), []byte{0x02, 0x01, 0x01, 0x00})
+	var abnormal *AbnormalResponseError
+	if !errors.As(err, &abnormal) || abnormal.ErrCode != 0x02 {
+		t.Fatalf("expected abnormal error, got %v", err)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, 50*time.Millisecond)
+	answerOnce(t, slave, func(req *MeterDlt645Protocol) []byte { return nil })
+	_, err := client.Read(context.Background(), []byte{0x02, 0x01, 0x01, 0x00})
+	if !errors.Is(err, ResponseTimeoutError) {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+}
+
+func TestClientReadAll(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	ident := []byte{0x02, 0x01, 0xFF, 0x00}
+	parser, err := NewMeterDataParser(2, nil, 0.1, 0, "V")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Register(ident, parser)
+	go func() {
+		reader := bufio.NewReader(slave)
+		for seq := byte(0); seq < 3; seq++ {
+			req := &MeterDlt645Protocol{}
+			if err := req.DecodeByBuf(reader); err != nil {
+				return
+			}
+			var frame []byte
+			value := &MeterData[uint64]{Value: 2200 + uint64(seq), Length: 2}
+			if seq == 0 {
+				frame, _ = BuildMasterReadResponse[uint64]("", req.Address, ident, value, true)
+			} else {
+				if req.ControlChar != ReadNextFrame || req.Data[4] != seq {
+					return
+				}
+				frame, _ = BuildMeterReadNextDataResponse[uint64]("", req.Address, ident, value, seq, seq < 2)
+			}
+			_, _ = slave.Write(frame)
+		}
+	}()
+	value, err := client.ReadAll(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, ok := value.([]float64)
+	if !ok || len(values) != 3 {
+		t.Fatalf("unexpected value %v", value)
+	}
+	for i, v := range values {
+		if want := float64(2200+i) * 0.1; v != want {
+			t.Fatalf("value %d: got %v, want %v", i, v, want)
+		}
+	}
+}
+
+func TestClientReadAllParallel(t *testing.T) {
+	simulator, client := startSimulator(t)
+	idents := [][]byte{{0x02, 0x01, 0x01, 0x00}, {0x02, 0x01, 0x02, 0x00}, {0x02, 0x01, 0x03, 0x00}}
+	for i, ident := range idents {
+		simulator.SetValue(ident, []byte{byte(i) << 4, 0x22})
+	}
+	//目录解析器在第一次使用时写入注册表，解析时修改解析器的状态
+	client.RegisterCatalogue()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8*len(idents))
+	for n := 0; n < 8; n++ {
+		for i, ident := range idents {
+			wg.Add(1)
+			go func(i int, ident []byte) {
+				defer wg.Done()
+				value, err := client.ReadAll(context.Background(), ident)
+				if err != nil {
+					errs <- err
+					return
+				}
+				if want := 220 + float64(i); value != want {
+					errs <- fmt.Errorf("% X: got %v, want %v", ident, value, want)
+				}
+			}(i, ident)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+}
+
+// tcpPipe 本地 TCP 连接，与 net.Pipe 不同，写入的数据在对端读取前由内核缓存
+func tcpPipe(t *testing.T) (net.Conn, net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	master, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return master, slave
+}
+
+func TestClientCancel(t *testing.T) {
+	master, slave := tcpPipe(t)
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	go func() {
+		reader := bufio.NewReader(slave)
+		for value := uint64(9999); ; value = 2219 {
+			req := &MeterDlt645Protocol{}
+			if err := req.DecodeByBuf(reader); err != nil {
+				return
+			}
+			if req.Address != "000000013310" {
+				continue
+			}
+			if value == 9999 {
+				//第一次请求的应答迟到
+				time.Sleep(100 * time.Millisecond)
+			}
+			frame, _ := BuildMasterReadResponse("", req.Address, ident, &MeterData[uint64]{Value: value, Length: 2}, false)
+			slave.Write(frame)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	start := time.Now()
+	if _, err := client.Read(ctx, ident); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 80*time.Millisecond {
+		t.Fatalf("cancel took %v", elapsed)
+	}
+	//迟到的应答在下一次请求前被丢弃
+	time.Sleep(150 * time.Millisecond)
+	resp, err := client.Read(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Data[4:], []byte{0x19, 0x22}) {
+		t.Fatalf("stale response was not discarded: % X", resp.Data)
+	}
+
+	//ctx 的截止时间早于超时时间
+	ctx, cancel = context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	other := NewClient(NewMeter("", "000000013311"), master, time.Second)
+	if _, err = other.Read(ctx, ident); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if _, err = client.Read(context.Background(), ident); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestClientLockCancel 等待其他请求完成时 ctx 取消或到达截止时间，立即返回
+func TestClientLockCancel(t *testing.T) {
+	master, slave := tcpPipe(t)
+	defer master.Close()
+	defer slave.Close()
+	client := NewClient(NewMeter("", "000000013310"), master, 0)
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	//第一个请求一直等不到应答，占用客户端
+	busy, stop := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Read(busy, ident)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := client.Read(ctx, ident); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("waiting for the lock took %v", elapsed)
+	}
+	stop()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected canceled, got %v", err)
+	}
+	//释放后可以继续请求，之前请求的应答会被丢弃
+	go func() {
+		reader := bufio.NewReader(slave)
+		for {
+			req := &MeterDlt645Protocol{}
+			if err := req.DecodeByBuf(reader); err != nil {
+				return
+			}
+			frame, _ := BuildMasterReadResponse[uint64]("", req.Address, ident, &MeterData[uint64]{Value: 2219, Length: 2}, false)
+			slave.Write(frame)
+		}
+	}()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if _, err := client.Read(ctx, ident); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	dlt645 "github.com/VaccariaSeed/go-dlt645-2007"
+)
+
+// wakeUpPrefix 唤醒前缀
+const wakeUpPrefix = "FEFEFEFE"
+
+// timeLayout -time 参数的格式
+const timeLayout = "2006-01-02 15:04:05"
+
+// buildTypes build 支持的报文类型
+var buildTypes = []string{"read", "read-next", "set", "read-addr", "set-addr", "broadcast-time", "freeze"}
+
+func runBuild(args []string, stdout io.Writer) error {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	typ := flags.String("type", "read", "报文类型: "+strings.Join(buildTypes, ", "))
+	addr := flags.String("addr", "", "表计地址，set-addr 时为新的通信地址")
+	di := flags.String("di", "", "数据标识，例如 02010100")
+	value := flags.String("value", "", "设定值，十进制整数，按 -len 字节编码为BCD，负数编码为带符号BCD")
+	hexValue := flags.String("hex", "", "设定值，十六进制，高字节在前")
+	length := flags.Uint("len", 0, "设定值的字节数")
+	pwd := flags.String("pwd", "02000000", "密码，PA P0 P1 P2")
+	operator := flags.String("op", "00000000", "操作者代码，C0 C1 C2 C3")
+	seq := flags.Uint("seq", 1, "读后续数据的帧序号")
+	block := flags.Uint("block", 0, "负荷记录块数")
+	ts := flags.String("time", "", "时间，格式 "+timeLayout+"，广播校时和冻结默认为当前时间")
+	wake := flags.Bool("wake", false, "添加 FE 唤醒前缀")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	prefix := ""
+	if *wake {
+		prefix = wakeUpPrefix
+	}
+	ti, hasTime, err := parseTime(*ts)
+	if err != nil {
+		return err
+	}
+	var frame []byte
+	switch *typ {
+	case "read":
+		ident, err := parseIdent(*di)
+		if err != nil {
+			return err
+		}
+		var given *time.Time
+		if hasTime {
+			given = &ti
+		}
+		frame, err = dlt645.BuildMasterReadRequest(prefix, *addr, ident, byte(*block), given)
+		if err != nil {
+			return err
+		}
+	case "read-next":
+		ident, err := parseIdent(*di)
+		if err != nil {
+			return err
+		}
+		frame, err = dlt645.BuildMasterReadNextDataRequest(prefix, *addr, ident, byte(*seq))
+		if err != nil {
+			return err
+		}
+	case "set":
+		ident, err := parseIdent(*di)
+		if err != nil {
+			return err
+		}
+		frame, err = buildSet(prefix, *addr, ident, *pwd, *operator, *value, *hexValue, byte(*length))
+		if err != nil {
+			return err
+		}
+	case "read-addr":
+		frame, err = dlt645.BuildMasterReadMeterAddrRequest(prefix)
+	case "set-addr":
+		frame, err = dlt645.BuildMasterSetMeterAddrRequest(prefix, *addr)
+	case "broadcast-time":
+		frame, err = dlt645.BuildBroadcastTimeCalibration(prefix, ti)
+	case "freeze":
+		frame, err = dlt645.BuildFreezeCommandRequest(prefix, *addr, ti)
+	default:
+		return fmt.Errorf("unknown type %q, expected one of %s", *typ, strings.Join(buildTypes, ", "))
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, strings.ToUpper(hex.EncodeToString(frame)))
+	return err
+}
+
+func buildSet(prefix, addr string, ident []byte, pwd, operator, value, hexValue string, length byte) ([]byte, error) {
+	pwdArr, err := parseHex(pwd, 4, "pwd")
+	if err != nil {
+		return nil, err
+	}
+	password, err := dlt645.PasswordFromBytes(pwdArr)
+	if err != nil {
+		return nil, err
+	}
+	operatorArr, err := parseHex(operator, 4, "op")
+	if err != nil {
+		return nil, err
+	}
+	meter := dlt645.NewMeter(prefix, addr)
+	switch {
+	case hexValue != "":
+		return meter.BuildMasterSetRequest(ident, password, operatorArr, hexValue, 0)
+	case value != "":
+		if length == 0 {
+			return nil, errors.New("-len is required with -value")
+		}
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return meter.BuildMasterSetRequest(ident, password, operatorArr, v, length)
+	default:
+		return nil, errors.New("-value or -hex is required")
+	}
+}
+
+func parseIdent(di string) ([]byte, error) {
+	if di == "" {
+		return nil, errors.New("-di is required")
+	}
+	return parseHex(di, 4, "di")
+}
+
+func parseHex(text string, size int, name string) ([]byte, error) {
+	data, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return nil, fmt.Errorf("-%s: %w", name, err)
+	}
+	if len(data) != size {
+		return nil, fmt.Errorf("-%s must be %d bytes", name, size)
+	}
+	return data, nil
+}
+
+func parseTime(text string) (time.Time, bool, error) {
+	if text == "" {
+		return time.Now(), false, nil
+	}
+	ti, err := time.ParseInLocation(timeLayout, text, time.Local)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("-time: %w", err)
+	}
+	return ti, true, nil
+}
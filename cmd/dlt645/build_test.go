@@ -0,0 +1,30 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunBuild(t *testing.T) {
+	var out bytes.Buffer
+	err := runBuild([]string{"-type", "read", "-addr", "000000013310", "-di", "02010100", "-wake"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(out.String()); got != "FEFEFEFE6810330100000068110433343435F916" {
+		t.Fatalf("unexpected frame %s", got)
+	}
+	out.Reset()
+	err = runBuild([]string{"-type", "set", "-addr", "000000013310", "-di", "04000103", "-value", "15", "-len", "1"}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := decodeFrame(out.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Ident != "04000103" || result.Value != 15.0 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
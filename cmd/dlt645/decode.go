@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	dlt645 "github.com/VaccariaSeed/go-dlt645-2007"
+)
+
+// decodeResult 报文解析结果
+type decodeResult struct {
+	Address     string `json:"address"`
+	ControlChar string `json:"controlChar"`
+	Name        string `json:"name"`
+	Direction   string `json:"direction"`
+	Data        string `json:"data"`
+	ErrCode     string `json:"errCode,omitempty"`
+	ErrText     string `json:"errText,omitempty"`
+	Ident       string `json:"ident,omitempty"`
+	IdentName   string `json:"identName,omitempty"`
+	Seq         *byte  `json:"seq,omitempty"`
+	Value       any    `json:"value,omitempty"`
+	Unit        string `json:"unit,omitempty"`
+	ValueErr    string `json:"valueError,omitempty"`
+}
+
+func runDecode(args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("decode", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "以JSON格式输出")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var frames []string
+	if flags.NArg() > 0 {
+		frames = append(frames, strings.Join(flags.Args(), ""))
+	} else {
+		scanner := bufio.NewScanner(stdin)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				frames = append(frames, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return err
+		}
+	}
+	if len(frames) == 0 {
+		return errors.New("no frame")
+	}
+	for i, frame := range frames {
+		result, err := decodeFrame(frame)
+		if err != nil {
+			return fmt.Errorf("%s: %w", frame, err)
+		}
+		if *asJSON {
+			if err = json.NewEncoder(stdout).Encode(result); err != nil {
+				return err
+			}
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(stdout)
+		}
+		printResult(stdout, result)
+	}
+	return nil
+}
+
+// decodeFrame 解析一帧十六进制报文，允许包含空白字符和 FE 唤醒前缀
+func decodeFrame(text string) (*decodeResult, error) {
+	text = strings.Join(strings.Fields(text), "")
+	frame, err := hex.DecodeString(text)
+	if err != nil {
+		return nil, err
+	}
+	for len(frame) > 0 && frame[0] == 0xFE {
+		frame = frame[1:]
+	}
+	pro := &dlt645.MeterDlt645Protocol{}
+	if err = pro.Decode(frame); err != nil {
+		return nil, err
+	}
+	result := &decodeResult{
+		Address:     pro.Address,
+		ControlChar: fmt.Sprintf("%02X", pro.ControlChar),
+		Name:        dlt645.ControlCharName(pro.ControlChar),
+		Direction:   direction(pro.ControlChar),
+		Data:        fmt.Sprintf("% X", pro.Data),
+	}
+	if pro.ControlChar&0xC0 == 0xC0 && len(pro.Data) > 0 {
+		result.ErrCode = fmt.Sprintf("%02X", pro.Data[0])
+		//安全认证的异常应答是2字节的安全认证错误信息字，不按错误信息字解析
+		if pro.ControlChar != dlt645.SecurityErrResponse {
+			result.ErrText = strings.TrimPrefix(dlt645.MeterError(pro.Data[0]).Error(), "dlt645_2007: ")
+		}
+		return result, nil
+	}
+	var value []byte
+	switch pro.ControlChar {
+	case dlt645.MainStationRequestFrame, dlt645.RespondingNormallyNoNext, dlt645.RespondingNormallyHasNext:
+		if len(pro.Data) >= 4 {
+			value = pro.Data[4:]
+		}
+	case dlt645.ReadNextFrame, dlt645.NextRespondingNormallyNoNext, dlt645.NextRespondingNormallyHasNext:
+		if len(pro.Data) >= 5 {
+			seq := pro.Data[len(pro.Data)-1]
+			result.Seq = &seq
+			value = pro.Data[4 : len(pro.Data)-1]
+		}
+	case dlt645.MasterSetRequest:
+		if len(pro.Data) >= 12 {
+			value = pro.Data[12:]
+		}
+	default:
+		return result, nil
+	}
+	if len(pro.Data) < 4 {
+		return result, nil
+	}
+	ident := []byte{pro.Data[3], pro.Data[2], pro.Data[1], pro.Data[0]}
+	result.Ident = strings.ToUpper(hex.EncodeToString(ident))
+	info, ok := dlt645.LookupDataIdent(ident)
+	if !ok {
+		return result, nil
+	}
+	result.IdentName = info.Name
+	//请求帧中 DI 之后是块数、时间等，不是数据
+	if pro.ControlChar == dlt645.MainStationRequestFrame || pro.ControlChar == dlt645.ReadNextFrame || len(value) == 0 {
+		return result, nil
+	}
+	decoder, err := info.Decoder(time.Local)
+	if err != nil {
+		return result, nil
+	}
+	result.Value, err = decoder.Decode(value)
+	if err != nil {
+		result.ValueErr = err.Error()
+		return result, nil
+	}
+	result.Unit = info.Unit
+	return result, nil
+}
+
+// direction 根据控制码的 D7 D6 D5 位描述传输方向、应答标志和后续帧标志
+func direction(code byte) string {
+	if code&0x80 == 0 {
+		return "主站→从站"
+	}
+	text := "从站→主站 正常应答"
+	if code&0x40 != 0 {
+		text = "从站→主站 异常应答"
+	}
+	if code&0x20 != 0 {
+		text += " 有后续帧"
+	}
+	return text
+}
+
+func printResult(w io.Writer, result *decodeResult) {
+	fmt.Fprintf(w, "地址:     %s\n", result.Address)
+	fmt.Fprintf(w, "控制码:   %s %s\n", result.ControlChar, result.Name)
+	fmt.Fprintf(w, "方向:     %s\n", result.Direction)
+	fmt.Fprintf(w, "数据域:   %s\n", result.Data)
+	if result.ErrCode != "" {
+		fmt.Fprintf(w, "错误码:   %s %s\n", result.ErrCode, result.ErrText)
+	}
+	if result.Ident != "" {
+		fmt.Fprintf(w, "数据标识: %s %s\n", result.Ident, result.IdentName)
+	}
+	if result.Seq != nil {
+		fmt.Fprintf(w, "帧序号:   %d\n", *result.Seq)
+	}
+	if result.Value != nil {
+		fmt.Fprintf(w, "值:       %v %s\n", result.Value, result.Unit)
+	}
+	if result.ValueErr != "" {
+		fmt.Fprintf(w, "值:       解析失败 %s\n", result.ValueErr)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"encoding/hex"
+	"testing"
+
+	dlt645 "github.com/VaccariaSeed/go-dlt645-2007"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	result, err := decodeFrame("FE FE FE FE 68 00 51 44 18 11 17 68 91 06 35 33 B3 35 36 83 45 16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ControlChar != "91" || result.Ident != "02800002" || result.Unit != "Hz" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if v, ok := result.Value.(float64); !ok || v < 50.029 || v > 50.031 {
+		t.Fatalf("unexpected value %v", result.Value)
+	}
+}
+
+func TestDecodeAbnormalFrame(t *testing.T) {
+	frame, _ := dlt645.BuildMeterAbnormalResponse("", "000000013310", dlt645.MeterErrNoData)
+	result, err := decodeFrame(hex.EncodeToString(frame))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.ErrCode != "02" || result.ErrText != "no requested data" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+}
@@ -0,0 +1,44 @@
+// dlt645 是 DL/T 645-2007 报文调试工具
+//
+//	dlt645 decode [-json] [hex]   解析报文，未给出 hex 时从标准输入逐行读取
+//	dlt645 build -type read -addr 000000013310 -di 02010100 [-wake]   生成报文
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	var err error
+	switch os.Args[1] {
+	case "decode":
+		err = runDecode(os.Args[2:], os.Stdin, os.Stdout)
+	case "build":
+		err = runBuild(os.Args[2:], os.Stdout)
+	case "help", "-h", "-help", "--help":
+		usage(os.Stdout)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "dlt645: unknown command %q\n", os.Args[1])
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dlt645:", err)
+		os.Exit(1)
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: dlt645 <command> [flags] [args]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	fmt.Fprintln(w, "  decode   解析报文，未给出报文时从标准输入逐行读取")
+	fmt.Fprintln(w, "  build    生成报文，运行 dlt645 build -h 查看参数")
+}
@@ -20,4 +20,125 @@ const (
 	FreezeCommand                 byte = 0x16 //冻结命令
 	FreezeCommandResponse         byte = 0x96
 	FreezeCommandErrorResponse    byte = 0xD6
+	RelayControlRequest           byte = 0x1C //跳合闸、报警、保电
+	RelayControlResponse          byte = 0x9C //跳合闸、报警、保电，从站正常应答
+	RelayControlErrResponse       byte = 0xDC //跳合闸、报警、保电，从站异常应答
+	DemandClearRequest            byte = 0x19 //最大需量清零
+	DemandClearResponse           byte = 0x99 //最大需量清零，从站正常应答
+	DemandClearErrResponse        byte = 0xD9 //最大需量清零，从站异常应答
+	MeterClearRequest             byte = 0x1A //电表清零
+	MeterClearResponse            byte = 0x9A //电表清零，从站正常应答
+	MeterClearErrResponse         byte = 0xDA //电表清零，从站异常应答
+	EventClearRequest             byte = 0x1B //事件清零
+	EventClearResponse            byte = 0x9B //事件清零，从站正常应答
+	EventClearErrResponse         byte = 0xDB //事件清零，从站异常应答
+	PasswordChangeRequest         byte = 0x18 //修改密码
+	PasswordChangeResponse        byte = 0x98 //修改密码，从站正常应答
+	PasswordChangeErrResponse     byte = 0xD8 //修改密码，从站异常应答
+	BaudChangeRequest             byte = 0x17 //更改通信速率
+	BaudChangeResponse            byte = 0x97 //更改通信速率，从站正常应答
+	BaudChangeErrResponse         byte = 0xD7 //更改通信速率，从站异常应答
+	SecurityRequest               byte = 0x03 //安全认证
+	SecurityResponse              byte = 0x83 //安全认证，从站正常应答
+	SecurityErrResponse           byte = 0xC3 //安全认证，从站异常应答
+	MultiOutputRequest            byte = 0x1D //多功能端子输出控制
+	MultiOutputResponse           byte = 0x9D //多功能端子输出控制，从站正常应答
+	MultiOutputErrResponse        byte = 0xDD //多功能端子输出控制，从站异常应答
+	Read1997Request               byte = 0x01 //DL/T 645-1997 读数据
+	Read1997Response              byte = 0x81 //DL/T 645-1997 读数据，从站正常应答，无后续帧
+	Read1997HasNextResponse       byte = 0xA1 //DL/T 645-1997 读数据，从站正常应答，有后续帧
+	Read1997ErrResponse           byte = 0xC1 //DL/T 645-1997 读数据，从站异常应答
+	ReadNext1997Request           byte = 0x02 //DL/T 645-1997 读后续数据
+	ReadNext1997Response          byte = 0x82 //DL/T 645-1997 读后续数据，从站正常应答，无后续帧
+	ReadNext1997HasNextResponse   byte = 0xA2 //DL/T 645-1997 读后续数据，从站正常应答，有后续帧
+	ReadNext1997ErrResponse       byte = 0xC2 //DL/T 645-1997 读后续数据，从站异常应答
+	Write1997Request              byte = 0x04 //DL/T 645-1997 写数据
+	Write1997Response             byte = 0x84 //DL/T 645-1997 写数据，从站正常应答
+	Write1997ErrResponse          byte = 0xC4 //DL/T 645-1997 写数据，从站异常应答
 )
+
+// RelayCommand 跳合闸、报警、保电的控制命令类型
+type RelayCommand byte
+
+const (
+	RelayTrip             RelayCommand = 0x1A //跳闸
+	RelayCloseAllow       RelayCommand = 0x1B //合闸允许
+	RelayCloseDirect      RelayCommand = 0x1C //直接合闸
+	RelayAlarm            RelayCommand = 0x2A //报警
+	RelayAlarmRelease     RelayCommand = 0x2B //报警解除
+	RelayGuarantee        RelayCommand = 0x3A //保电
+	RelayGuaranteeRelease RelayCommand = 0x3B //保电解除
+)
+
+// MultiFunctionOutput 多功能端子输出信号类别
+type MultiFunctionOutput byte
+
+const (
+	OutputClockPulse   MultiFunctionOutput = 0x00 //时钟秒脉冲
+	OutputDemandPeriod MultiFunctionOutput = 0x01 //需量周期
+	OutputSlotSwitch   MultiFunctionOutput = 0x02 //时段投切
+)
+
+// controlCharNames 控制码名称
+var controlCharNames = map[byte]string{
+	MainStationRequestFrame:       "读数据",
+	RespondingNormallyNoNext:      "读数据正常应答(无后续帧)",
+	RespondingNormallyHasNext:     "读数据正常应答(有后续帧)",
+	SlaveErrResponse:              "读数据异常应答",
+	ReadNextFrame:                 "读后续数据",
+	NextRespondingNormallyNoNext:  "读后续数据正常应答(无后续帧)",
+	NextRespondingNormallyHasNext: "读后续数据正常应答(有后续帧)",
+	NextSlaveErrResponse:          "读后续数据异常应答",
+	MasterSetRequest:              "写数据",
+	MeterSetResponse:              "写数据正常应答",
+	MeterSetErrResponse:           "写数据异常应答",
+	MasterReadMeterAddrRequest:    "读通信地址",
+	MeterAddrResponse:             "读通信地址正常应答",
+	MasterSetMeterAddrRequest:     "写通信地址",
+	MeterSetMeterAddrResponse:     "写通信地址正常应答",
+	BroadcastTimeCalibration:      "广播校时",
+	FreezeCommand:                 "冻结命令",
+	FreezeCommandResponse:         "冻结命令正常应答",
+	FreezeCommandErrorResponse:    "冻结命令异常应答",
+	RelayControlRequest:           "跳合闸、报警、保电",
+	RelayControlResponse:          "跳合闸、报警、保电正常应答",
+	RelayControlErrResponse:       "跳合闸、报警、保电异常应答",
+	DemandClearRequest:            "最大需量清零",
+	DemandClearResponse:           "最大需量清零正常应答",
+	DemandClearErrResponse:        "最大需量清零异常应答",
+	MeterClearRequest:             "电表清零",
+	MeterClearResponse:            "电表清零正常应答",
+	MeterClearErrResponse:         "电表清零异常应答",
+	EventClearRequest:             "事件清零",
+	EventClearResponse:            "事件清零正常应答",
+	EventClearErrResponse:         "事件清零异常应答",
+	PasswordChangeRequest:         "修改密码",
+	PasswordChangeResponse:        "修改密码正常应答",
+	PasswordChangeErrResponse:     "修改密码异常应答",
+	BaudChangeRequest:             "更改通信速率",
+	BaudChangeResponse:            "更改通信速率正常应答",
+	BaudChangeErrResponse:         "更改通信速率异常应答",
+	SecurityRequest:               "安全认证",
+	SecurityResponse:              "安全认证正常应答",
+	SecurityErrResponse:           "安全认证异常应答",
+	MultiOutputRequest:            "多功能端子输出控制",
+	MultiOutputResponse:           "多功能端子输出控制正常应答",
+	MultiOutputErrResponse:        "多功能端子输出控制异常应答",
+	Read1997Request:               "读数据(1997)",
+	Read1997Response:              "读数据正常应答(1997,无后续帧)",
+	Read1997HasNextResponse:       "读数据正常应答(1997,有后续帧)",
+	Read1997ErrResponse:           "读数据异常应答(1997)",
+	ReadNext1997Request:           "读后续数据(1997)",
+	ReadNext1997Response:          "读后续数据正常应答(1997,无后续帧)",
+	ReadNext1997HasNextResponse:   "读后续数据正常应答(1997,有后续帧)",
+	ReadNext1997ErrResponse:       "读后续数据异常应答(1997)",
+	Write1997Request:              "写数据(1997)",
+	Write1997Response:             "写数据正常应答(1997)",
+	Write1997ErrResponse:          "写数据异常应答(1997)",
+}
+
+// ControlCharName 获取控制码名称，未知的控制码返回空字符串
+// code 控制码
+func ControlCharName(code byte) string {
+	return controlCharNames[code]
+}
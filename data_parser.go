@@ -24,6 +24,17 @@ func NewMeterDataParser(size int, order binary.ByteOrder, ratio, offset float64,
 	return &MeterDataParser{size: size, order: order, ratio: ratio, offset: offset, unit: uint}, nil
 }
 
+// NewSignedMeterDataParser 创建一个带符号BCD的数据解析器，最高字节的最高位为符号位，1表示负数
+// 用于功率、电流、功率因数等有方向的数据
+func NewSignedMeterDataParser(size int, order binary.ByteOrder, ratio, offset float64, uint string) (*MeterDataParser, error) {
+	parser, err := NewMeterDataParser(size, order, ratio, offset, uint)
+	if err != nil {
+		return nil, err
+	}
+	parser.signed = true
+	return parser, nil
+}
+
 // MeterDataParser 数据解析器
 type MeterDataParser struct {
 	size   int              //数据长度
@@ -31,9 +42,15 @@ type MeterDataParser struct {
 	ratio  float64          //倍率
 	offset float64          //偏移量，偏移量是减法运算
 	unit   string           //单位
+	signed bool             //是否是带符号BCD
 	data   []float64
 }
 
+// Size 单个数据项的字节数
+func (p *MeterDataParser) Size() int {
+	return p.size
+}
+
 func (p *MeterDataParser) flush() {
 	p.data = nil
 }
@@ -85,13 +102,21 @@ func (p *MeterDataParser) parser(data []byte) (float64, error) {
 	if len(data) != p.size {
 		return 0, LengthMismatchError
 	}
-	original := hex.EncodeToString(reverseBytes(data))
+	bcd := reverseBytes(data)
 	if p.order == binary.BigEndian {
-		original = hex.EncodeToString(data)
+		bcd = append([]byte(nil), data...)
 	}
-	val, err := strconv.Atoi(original)
+	negative := false
+	if p.signed {
+		negative = bcd[0]&0x80 != 0
+		bcd[0] &= 0x7F
+	}
+	val, err := strconv.Atoi(hex.EncodeToString(bcd))
 	if err != nil {
 		return 0, err
 	}
+	if negative {
+		val = -val
+	}
 	return float64(val), nil
 }
@@ -0,0 +1,45 @@
+package go_dlt645_2007
+
+import "testing"
+
+func TestSignedMeterDataParser(t *testing.T) {
+	parser, err := NewSignedMeterDataParser(3, nil, 0.0001, 0, "kW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//-1.2345 kW
+	value, err := parser.Decode([]byte{0x45, 0x23, 0x81})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := value.(float64); v > -1.2344 || v < -1.2346 {
+		t.Fatalf("unexpected value %v", v)
+	}
+}
+
+func TestInt64ToBytes(t *testing.T) {
+	cases := []struct {
+		value  int64
+		length byte
+		want   []byte
+	}{
+		{12345, 3, []byte{0x45, 0x23, 0x01}},
+		{-12345, 3, []byte{0x45, 0x23, 0x81}},
+		{-1, 2, []byte{0x01, 0x80}},
+	}
+	for _, c := range cases {
+		got, err := int64ToBytes(c.value, c.length)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(c.want) {
+			t.Fatalf("%d: got % X, want % X", c.value, got, c.want)
+		}
+	}
+	if _, err := int64ToBytes(-800000, 3); err == nil {
+		t.Fatal("expected overflow error")
+	}
+	if _, err := uint64ToBytes(1000000, 3); err == nil {
+		t.Fatal("expected overflow error")
+	}
+}
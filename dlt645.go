@@ -3,10 +3,8 @@ package go_dlt645_2007
 import (
 	"bufio"
 	"bytes"
-	"encoding/binary"
-	"encoding/hex"
 	"errors"
-	"fmt"
+	"io"
 	"strings"
 )
 
@@ -18,6 +16,13 @@ const (
 	disturb          byte   = 0x33
 )
 
+var (
+	errFrameLength = errors.New("not enough frame length")
+	errStartChar   = errors.New("start char 2 != 68H")
+	errCs          = errors.New("cs error")
+	errEndChar     = errors.New("end char != 16H")
+)
+
 type MeterDlt645Protocol struct {
 	prefix string //通配前缀
 	//startChar1  byte   //帧起始符
@@ -33,88 +38,80 @@ type MeterDlt645Protocol struct {
 
 // Decode 解码
 func (m *MeterDlt645Protocol) Decode(frame []byte) error {
-	if len(frame) < 12 {
-		return errors.New("not enough frame length")
+	m.Data = nil
+	if err := m.ParseFrame(frame); err != nil {
+		return err
 	}
-	buf := bufio.NewReader(bytes.NewBuffer(frame))
-	return m.DecodeByBuf(buf)
+	m.original = append([]byte(nil), m.original...)
+	return nil
 }
 
-func (m *MeterDlt645Protocol) DecodeByBuf(buf *bufio.Reader) error {
+// ParseFrame 解码，不修改 frame，也不分配内存：
+// 数据域复用 m.Data 的容量，地址与上一次相同时复用 m.Address，Frame() 引用 frame 中的原始报文
+// 重复使用同一个对象解码时，上一次解码得到的 m.Data 会被覆盖，需要保留时使用 Decode
+// frame 报文，帧起始符之前的唤醒前缀等数据会被跳过
+func (m *MeterDlt645Protocol) ParseFrame(frame []byte) error {
 	m.original = nil
-	var startChar byte
-	for {
-		err := binary.Read(buf, binary.BigEndian, &startChar)
-		if err != nil {
-			return err
-		}
-		if startChar != dlt645StartChar {
-			continue
-		}
-		break
-	}
-	//帧起始符
-	snap := []byte{dlt645StartChar}
-	//地址域, 低字节在前，高字节在后
-	var address = make([]byte, 6)
-	err := binary.Read(buf, binary.BigEndian, &address)
-	if err != nil {
-		return err
+	start := bytes.IndexByte(frame, dlt645StartChar)
+	if start < 0 || len(frame)-start < frameMinLength {
+		return errFrameLength
 	}
-	snap = append(snap, address...)
-	m.Address = hex.EncodeToString(reverseBytes(address))
-	//帧起始符
-	err = binary.Read(buf, binary.BigEndian, &startChar)
-	if err != nil {
-		return err
+	frame = frame[start:]
+	if frame[7] != dlt645StartChar {
+		return errStartChar
 	}
-	if startChar != dlt645StartChar {
-		return fmt.Errorf("start char 2 != 68H")
+	size := frameMinLength + int(frame[9])
+	if len(frame) < size {
+		return errFrameLength
 	}
-	//控制码
-	err = binary.Read(buf, binary.BigEndian, &m.ControlChar)
-	if err != nil {
-		return err
+	frame = frame[:size]
+	if frame[size-2] != m.cs(frame[:size-2]) {
+		return errCs
 	}
-	//数据域长度,读数据时 L≤200，写数据时 L≤50，L=0 表示无数据域
-	err = binary.Read(buf, binary.BigEndian, &m.Length)
-	if err != nil {
-		return err
+	if frame[size-1] != dlt645EndChar {
+		return errEndChar
+	}
+	m.setAddress(frame[1:7])
+	m.ControlChar = frame[8]
+	m.Length = frame[9]
+	m.Data = m.Data[:0]
+	for _, b := range frame[frameHeaderLength : size-2] {
+		m.Data = append(m.Data, b-disturb)
 	}
-	snap = append(snap, dlt645StartChar, m.ControlChar, m.Length)
-	//数据域,传输时发送方按字节进行加33H 处理，接收方按字节进行减33H 处理
-	if m.Length > 0 {
-		m.Data = make([]byte, m.Length)
-		err = binary.Read(buf, binary.BigEndian, &m.Data)
+	m.original = frame
+	return nil
+}
+
+// DecodeByBuf 从 buf 中读取并解码一帧报文，帧起始符之前的数据会被跳过
+// 每次为报文和数据域分配新的内存，解码结果可以一直保留；不分配内存的解码使用 ParseFrame
+func (m *MeterDlt645Protocol) DecodeByBuf(buf *bufio.Reader) error {
+	m.original = nil
+	for {
+		b, err := buf.ReadByte()
 		if err != nil {
 			return err
 		}
-		snap = append(snap, m.Data...)
-		for i, b := range m.Data {
-			m.Data[i] = b - disturb
+		if b == dlt645StartChar {
+			break
 		}
 	}
-	//校验码
-	var cs byte
-	err = binary.Read(buf, binary.BigEndian, &cs)
-	if err != nil {
+	var header [frameHeaderLength]byte
+	header[0] = dlt645StartChar
+	if _, err := io.ReadFull(buf, header[1:]); err != nil {
 		return err
 	}
-	//计算校验码
-	if cs != m.cs(snap) {
-		return errors.New("cs error")
+	if header[7] != dlt645StartChar {
+		return errStartChar
 	}
-	//结束符
-	var endChar byte
-	err = binary.Read(buf, binary.BigEndian, &endChar)
-	if err != nil {
+	//报文和数据域共用一块内存
+	size := frameMinLength + int(header[9])
+	frame := make([]byte, size+int(header[9]))
+	copy(frame, header[:])
+	if _, err := io.ReadFull(buf, frame[frameHeaderLength:size]); err != nil {
 		return err
 	}
-	if endChar != dlt645EndChar {
-		return fmt.Errorf("end char != 16H")
-	}
-	m.original = append(snap, cs, endChar)
-	return nil
+	m.Data = frame[size:size]
+	return m.ParseFrame(frame[:size:size])
 }
 
 
@@ -133,43 +130,105 @@ func (m *MeterDlt645Protocol) cs(frame []byte) byte {
 }
 
 func (m *MeterDlt645Protocol) Encode() ([]byte, error) {
-	frame := []byte{dlt645StartChar}
+	return m.AppendFrame(nil)
+}
+
+// AppendFrame 编码并把报文追加到 dst 之后，返回追加后的切片，不修改 m；dst 容量足够时不分配内存
+// 出错时返回原来的 dst，不会追加不完整的报文
+// dst 目标缓冲区，可以传入 buf[:0] 复用
+func (m *MeterDlt645Protocol) AppendFrame(dst []byte) ([]byte, error) {
 	if len(m.Address) > 12 {
-		return nil, fmt.Errorf("address too long")
+		return dst, errors.New("address too long")
 	} else if len(m.Address) == 0 {
-		return nil, fmt.Errorf("address is empty")
-	} else {
-		for len(m.Address) < 12 {
-			m.Address = "0" + m.Address
-		}
+		return dst, errors.New("address is empty")
 	}
-	addr, err := hex.DecodeString(m.Address)
-	if err != nil {
-		return nil, errors.New("invalid address")
-	}
-	frame = append(frame, reverseBytes(addr)...)
-	if m.Data == nil {
-		frame = append(frame, dlt645StartChar, m.ControlChar, 0x00)
-	} else {
-		frame = append(frame, dlt645StartChar, m.ControlChar, byte(len(m.Data)))
-		for i, b := range m.Data {
-			m.Data[i] = b + disturb
-		}
-		frame = append(frame, m.Data...)
+	if len(m.Data) > 0xFF {
+		return dst, errors.New("data too long")
+	}
+	var addr [6]byte
+	if !parseAddress(&addr, m.Address) {
+		return dst, errors.New("invalid address")
 	}
-	//计算cs
-	frame = append(frame, m.cs(frame), dlt645EndChar)
 	if strings.TrimSpace(m.prefix) != "" {
-		pf, pfErr := hex.DecodeString(m.prefix)
-		if pfErr != nil {
-			return nil, err
+		var ok bool
+		if dst, ok = appendHex(dst, m.prefix); !ok {
+			return dst, errors.New("invalid prefix")
 		}
-		frame = append(pf, frame...)
 	}
-	return frame, nil
+	start := len(dst)
+	dst = append(dst, dlt645StartChar, addr[0], addr[1], addr[2], addr[3], addr[4], addr[5], dlt645StartChar, m.ControlChar, byte(len(m.Data)))
+	for _, b := range m.Data {
+		dst = append(dst, b+disturb)
+	}
+	//计算cs
+	return append(dst, m.cs(dst[start:]), dlt645EndChar), nil
 }
 
 // ObtainDataLen 获取数据域的长度
 func (m *MeterDlt645Protocol) ObtainDataLen() int {
 	return len(m.Data) + 1
 }
+
+// setAddress 设置地址域，与当前地址相同时不分配内存
+// addr 报文中的地址域，低字节在前
+func (m *MeterDlt645Protocol) setAddress(addr []byte) {
+	const digits = "0123456789abcdef"
+	if len(m.Address) == 12 {
+		same := true
+		for i := 0; i < 6 && same; i++ {
+			b := addr[5-i]
+			same = m.Address[2*i] == digits[b>>4] && m.Address[2*i+1] == digits[b&0x0F]
+		}
+		if same {
+			return
+		}
+	}
+	var text [12]byte
+	for i := 0; i < 6; i++ {
+		b := addr[5-i]
+		text[2*i], text[2*i+1] = digits[b>>4], digits[b&0x0F]
+	}
+	m.Address = string(text[:])
+}
+
+// parseAddress 把地址字符串转换为报文中的地址域(低字节在前)，不足12位时高位补0
+func parseAddress(addr *[6]byte, address string) bool {
+	*addr = [6]byte{}
+	for i := 0; i < len(address); i++ {
+		v, ok := hexValue(address[len(address)-1-i])
+		if !ok {
+			return false
+		}
+		addr[i/2] |= v << (4 * (i % 2))
+	}
+	return true
+}
+
+// appendHex 把十六进制字符串解码后追加到 dst，s 不是合法的十六进制字符串时返回原来的 dst
+func appendHex(dst []byte, s string) ([]byte, bool) {
+	if len(s)%2 != 0 {
+		return dst, false
+	}
+	start := len(dst)
+	for i := 0; i < len(s); i += 2 {
+		hi, ok1 := hexValue(s[i])
+		lo, ok2 := hexValue(s[i+1])
+		if !ok1 || !ok2 {
+			return dst[:start], false
+		}
+		dst = append(dst, hi<<4|lo)
+	}
+	return dst, true
+}
+
+func hexValue(c byte) (byte, bool) {
+	switch {
+	case c >= '0' && c <= '9':
+		return c - '0', true
+	case c >= 'a' && c <= 'f':
+		return c - 'a' + 10, true
+	case c >= 'A' && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,259 @@
+package go_dlt645_2007
+
+import (
+	"encoding/hex"
+	"errors"
+)
+
+// DL/T 645-1997 与 2007 的帧格式相同，区别在于数据标识为2字节(DI1 DI0)、控制码不同、写数据没有操作者代码。
+// 广播校时的控制码与 2007 相同(08H)，直接使用 BuildBroadcastTimeCalibration。
+// 1997 异常应答的错误信息字：bit0 非法数据，bit1 数据标识错，bit2 密码错，bit4 年时区数超，bit5 日时段数超，bit6 费率数超，
+// 与 2007 的 MeterError 按位对应
+
+// ProtocolVersion 规约版本
+type ProtocolVersion int
+
+const (
+	Version2007 ProtocolVersion = 2007 //DL/T 645-2007
+	Version1997 ProtocolVersion = 1997 //DL/T 645-1997
+)
+
+func (v ProtocolVersion) String() string {
+	switch v {
+	case Version2007:
+		return "DL/T 645-2007"
+	case Version1997:
+		return "DL/T 645-1997"
+	}
+	return "unknown"
+}
+
+// BuildMasterReadRequest1997 创建一个 1997 读数据请求帧
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识，DI1 在前
+func BuildMasterReadRequest1997(prefix, meterId string, ident []byte) ([]byte, error) {
+	if len(ident) != 2 {
+		return nil, errors.New("data ident length error")
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: reverseBytes(ident), ControlChar: Read1997Request}
+	return statute.Encode()
+}
+
+// BuildMasterReadResponse1997 创建一个 1997 读数据的正常应答
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识，DI1 在前
+// value 值
+// hasNext 是否存在后续帧，true-存在， false-不存在
+func BuildMasterReadResponse1997[T ScalarOrVector](prefix, meterId string, ident []byte, value *MeterData[T], hasNext bool) ([]byte, error) {
+	if len(ident) != 2 {
+		return nil, errors.New("data ident length error")
+	}
+	controlCode := Read1997Response
+	if hasNext {
+		controlCode = Read1997HasNextResponse
+	}
+	data := reverseBytes(ident)
+	if value != nil {
+		valArr, err := toLittleEndianBytes(value)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, valArr...)
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: data, ControlChar: controlCode}
+	return statute.Encode()
+}
+
+// BuildMeterAbnormalResponse1997 创建一个 1997 读数据的异常应答
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误信息字
+func BuildMeterAbnormalResponse1997(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{byte(errCode)}, ControlChar: Read1997ErrResponse}
+	return statute.Encode()
+}
+
+// BuildMasterReadNextRequest1997 创建一个 1997 读后续数据请求帧
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识，DI1 在前
+func BuildMasterReadNextRequest1997(prefix, meterId string, ident []byte) ([]byte, error) {
+	if len(ident) != 2 {
+		return nil, errors.New("data ident length error")
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: reverseBytes(ident), ControlChar: ReadNext1997Request}
+	return statute.Encode()
+}
+
+// BuildMasterSetRequest1997 创建一个 1997 写数据请求帧
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识，DI1 在前
+// pwd 密码
+// value 设置的值
+func BuildMasterSetRequest1997[T ScalarOrVector](prefix, meterId string, ident []byte, pwd Password, value *MeterData[T]) ([]byte, error) {
+	if len(ident) != 2 {
+		return nil, errors.New("data ident length error")
+	}
+	data := append(reverseBytes(ident), pwd.Bytes()...)
+	valArr, err := toLittleEndianBytes(value)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, valArr...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: data, ControlChar: Write1997Request}
+	return statute.Encode()
+}
+
+// BuildMeterSetResponse1997 创建一个 1997 写数据的正常应答
+// prefix 通配唤醒前缀
+// meterId 表地址
+func BuildMeterSetResponse1997(prefix, meterId string) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: Write1997Response}
+	return statute.Encode()
+}
+
+// BuildMeterSetErrResponse1997 创建一个 1997 写数据的异常应答
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误信息字
+func BuildMeterSetErrResponse1997(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{byte(errCode)}, ControlChar: Write1997ErrResponse}
+	return statute.Encode()
+}
+
+// NewMeterDataCodec1997 创建 1997 的数据解析器，结果通过 MeterDataReceiver 返回：
+// 读数据和读后续数据的应答调用 MeterReadResponse 或 MeterValueReceiver.MeterReadValue(数据标识为2字节，帧序号为0)，
+// 写数据的应答调用 MeterReqMasterSet
+func NewMeterDataCodec1997(receiver MeterDataReceiver) *MeterDataCodec1997 {
+	valueReceiver, _ := receiver.(MeterValueReceiver)
+	return &MeterDataCodec1997{receiver: receiver, valueReceiver: valueReceiver, parsers: make(map[string]*MeterDataParser), decoders: make(map[string]DataDecoder)}
+}
+
+// MeterDataCodec1997 1997 数据解析器
+type MeterDataCodec1997 struct {
+	receiver      MeterDataReceiver
+	valueReceiver MeterValueReceiver //接收者没有实现 MeterValueReceiver 时为 nil
+	parsers       map[string]*MeterDataParser
+	decoders      map[string]DataDecoder
+}
+
+// Register 注册数据解析器
+// ident 数据标识，DI1 在前
+// parser 数据解析器
+func (m *MeterDataCodec1997) Register(ident []byte, parser *MeterDataParser) {
+	m.parsers[hex.EncodeToString(reverseBytes(ident))] = parser
+}
+
+// RegisterDecoder 注册数据域解码器，解码结果通过 MeterValueReceiver.MeterReadValue 返回
+// ident 数据标识，DI1 在前
+// decoder 数据域解码器
+func (m *MeterDataCodec1997) RegisterDecoder(ident []byte, decoder DataDecoder) {
+	m.decoders[hex.EncodeToString(reverseBytes(ident))] = decoder
+}
+
+func (m *MeterDataCodec1997) ParseData(funcCode byte, data []byte) {
+	if m.receiver == nil {
+		return
+	}
+	switch funcCode {
+	case Read1997Response, Read1997HasNextResponse, ReadNext1997Response, ReadNext1997HasNextResponse:
+		m.parseReadResponse(funcCode, data)
+	case Read1997ErrResponse, ReadNext1997ErrResponse:
+		m.receiver.MeterReadErrorResponse(funcCode, obtainErrCode(data))
+	case Write1997Response, Write1997ErrResponse:
+		m.receiver.MeterReqMasterSet(funcCode == Write1997Response, obtainErrCode(data))
+	default:
+		m.receiver.ErrorData(funcCode, data, FuncCodeError)
+	}
+}
+
+func (m *MeterDataCodec1997) parseReadResponse(funcCode byte, data []byte) {
+	if len(data) < 2 {
+		m.receiver.ErrorData(funcCode, data, DataDomainError)
+		return
+	}
+	hasNext := funcCode == Read1997HasNextResponse || funcCode == ReadNext1997HasNextResponse
+	ident := data[:2]
+	key := hex.EncodeToString(ident)
+	if parser, ok := m.parsers[key]; ok {
+		parser.flush()
+		if len(data) == 2 {
+			m.receiver.MeterReadResponse(reverseBytes(ident), nil, hasNext, 0)
+			return
+		}
+		if err := parser.decode(data[2:]); err != nil {
+			m.receiver.ErrorData(funcCode, data, err)
+			return
+		}
+		m.receiver.MeterReadResponse(reverseBytes(ident), parser, hasNext, 0)
+	} else if decoder, ok := m.decoders[key]; ok && m.valueReceiver != nil {
+		if len(data) == 2 {
+			m.valueReceiver.MeterReadValue(reverseBytes(ident), nil, hasNext, 0)
+			return
+		}
+		value, err := decoder.Decode(data[2:])
+		if err != nil {
+			m.receiver.ErrorData(funcCode, data, err)
+			return
+		}
+		m.valueReceiver.MeterReadValue(reverseBytes(ident), value, hasNext, 0)
+	} else {
+		m.receiver.MeterDefaultReadResponse(funcCode, data)
+	}
+}
+
+// NewMasterDataCodec1997 创建 1997 的主站请求解析器，结果通过 MasterDataReceiver 返回：
+// 读数据调用 MasterReadRequest，读后续数据调用 MasterReadNextRequest(帧序号为0)，
+// 写数据调用 MasterSetRequest(操作者代码为 nil)，广播校时调用 BroadcastTimeCalibration，数据标识为报文中的2字节
+func NewMasterDataCodec1997(receiver MasterDataReceiver) *MasterDataCodec1997 {
+	return &MasterDataCodec1997{codec: NewMasterDataCodec(receiver)}
+}
+
+// MasterDataCodec1997 1997 主站请求解析器
+type MasterDataCodec1997 struct {
+	codec *MasterDataCodec
+}
+
+// RegisterPassword 注册从站的密码，注册过密码后写数据请求先校验密码，校验失败时以 PasswordError 调用 ErrorData
+// pwd 密码
+func (m *MasterDataCodec1997) RegisterPassword(pwd Password) {
+	m.codec.RegisterPassword(pwd)
+}
+
+func (m *MasterDataCodec1997) ParseData(funcCode byte, data []byte) {
+	receiver := m.codec.receiver
+	if receiver == nil {
+		return
+	}
+	switch funcCode {
+	case Read1997Request:
+		if len(data) < 2 {
+			receiver.ErrorData(funcCode, data, DataDomainError)
+			return
+		}
+		receiver.MasterReadRequest(&MasterReadRequestModel{ident: data[:2]})
+	case ReadNext1997Request:
+		if len(data) < 2 {
+			receiver.ErrorData(funcCode, data, DataDomainError)
+			return
+		}
+		receiver.MasterReadNextRequest(data[:2], 0)
+	case Write1997Request:
+		if len(data) < 6 {
+			receiver.ErrorData(funcCode, data, DataDomainError)
+			return
+		}
+		pwd, _ := PasswordFromBytes(data[2:6])
+		if !m.codec.checkPassword(funcCode, data, pwd) {
+			return
+		}
+		receiver.MasterSetRequest(data[:2], pwd, nil, data[6:])
+	case BroadcastTimeCalibration:
+		m.codec.parseBroadcastTimeCalibration(data)
+	default:
+		receiver.ErrorData(funcCode, data, FuncCodeError)
+	}
+}
@@ -0,0 +1,216 @@
+package go_dlt645_2007
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// meterReceiver1997 只实现 1997 解析器会调用的方法
+type meterReceiver1997 struct {
+	MeterDataReceiver
+	ident   []byte
+	value   float64
+	set     bool
+	errCode MeterError
+}
+
+func (r *meterReceiver1997) MeterReadResponse(ident []byte, parser *MeterDataParser, hasNext bool, seq byte) {
+	r.ident = ident
+	r.value, _ = parser.ObtainValue()
+}
+
+func (r *meterReceiver1997) MeterReadErrorResponse(funcCode byte, errCode MeterError) {
+	r.errCode = errCode
+}
+
+func (r *meterReceiver1997) MeterReqMasterSet(isSuccess bool, errCode MeterError) {
+	r.set, r.errCode = isSuccess, errCode
+}
+
+type masterReceiver1997 struct {
+	MasterDataReceiver
+	ident []byte
+	pwd   Password
+	data  []byte
+	err   error
+}
+
+func (r *masterReceiver1997) MasterReadRequest(req *MasterReadRequestModel) {
+	r.ident = req.ObtainIdent()
+}
+
+func (r *masterReceiver1997) MasterSetRequest(ident []byte, pwd Password, operator []byte, data []byte) {
+	r.ident, r.pwd, r.data = ident, pwd, data
+}
+
+func (r *masterReceiver1997) ErrorData(funcCode byte, data []byte, err error) {
+	r.err = err
+}
+
+func TestBuildMasterReadRequest1997(t *testing.T) {
+	frame, err := BuildMasterReadRequest1997("", "000000013310", []byte{0x90, 0x10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0x68, 0x10, 0x33, 0x01, 0x00, 0x00, 0x00, 0x68, 0x01, 0x02, 0x43, 0xC3, 0x1D, 0x16}
+	if !bytes.Equal(frame, expected) {
+		t.Fatalf("unexpected frame % X", frame)
+	}
+	if _, err = BuildMasterReadRequest1997("", "000000013310", []byte{0x00, 0x01, 0x00, 0x00}); err == nil {
+		t.Fatal("expected ident length error")
+	}
+}
+
+func TestCodec1997(t *testing.T) {
+	meter := NewMeter("", "000000013310")
+	//主站读数据
+	frame, _ := meter.BuildMasterReadRequest1997([]byte{0x90, 0x10})
+	pro := &MeterDlt645Protocol{}
+	if err := pro.Decode(frame); err != nil {
+		t.Fatal(err)
+	}
+	master := &masterReceiver1997{}
+	NewMasterDataCodec1997(master).ParseData(pro.ControlChar, pro.Data)
+	if !bytes.Equal(master.ident, []byte{0x10, 0x90}) {
+		t.Fatalf("unexpected ident % X", master.ident)
+	}
+
+	//从站应答
+	frame, _ = BuildMasterReadResponse1997("", "000000013310", []byte{0x90, 0x10}, &MeterData[uint64]{Value: 123456, Length: 4}, false)
+	if err := pro.Decode(frame); err != nil {
+		t.Fatal(err)
+	}
+	receiver := &meterReceiver1997{}
+	codec := NewMeterDataCodec1997(receiver)
+	parser, _ := NewMeterDataParser(4, nil, 0.01, 0, "kWh")
+	codec.Register([]byte{0x90, 0x10}, parser)
+	codec.ParseData(pro.ControlChar, pro.Data)
+	if !bytes.Equal(receiver.ident, []byte{0x90, 0x10}) || receiver.value < 1234.559 || receiver.value > 1234.561 {
+		t.Fatalf("unexpected response % X %v", receiver.ident, receiver.value)
+	}
+	frame, _ = BuildMeterAbnormalResponse1997("", "000000013310", MeterErrUnauthorized)
+	pro.Decode(frame)
+	codec.ParseData(pro.ControlChar, pro.Data)
+	if receiver.errCode != MeterErrUnauthorized {
+		t.Fatalf("unexpected error code %02X", byte(receiver.errCode))
+	}
+
+	//写数据
+	pwd := Password{Level: PasswordLevel02, Code: [3]byte{0x56, 0x34, 0x12}}
+	frame, _ = meter.BuildMasterSetRequest1997([]byte{0xC0, 0x32}, pwd, uint64(15), 1)
+	pro.Decode(frame)
+	masterCodec := NewMasterDataCodec1997(master)
+	masterCodec.ParseData(pro.ControlChar, pro.Data)
+	if !bytes.Equal(master.ident, []byte{0x32, 0xC0}) || master.pwd != pwd || !bytes.Equal(master.data, []byte{0x15}) {
+		t.Fatalf("unexpected set request %+v", master)
+	}
+	masterCodec.RegisterPassword(Password{Level: PasswordLevel02})
+	masterCodec.ParseData(pro.ControlChar, pro.Data)
+	if !errors.Is(master.err, PasswordError) {
+		t.Fatalf("expected password error, got %v", master.err)
+	}
+	frame, _ = BuildMeterSetResponse1997("", "000000013310")
+	pro.Decode(frame)
+	codec.ParseData(pro.ControlChar, pro.Data)
+	if !receiver.set {
+		t.Fatal("expected set response")
+	}
+}
+
+// serve1997 模拟只支持 1997 的表计，忽略 2007 的请求
+func serve1997(conn net.Conn) {
+	reader := bufio.NewReader(conn)
+	for {
+		req := &MeterDlt645Protocol{}
+		if err := req.DecodeByBuf(reader); err != nil {
+			return
+		}
+		if req.ControlChar != Read1997Request {
+			continue
+		}
+		frame, _ := BuildMasterReadResponse1997("", req.Address, reverseBytes(req.Data), &MeterData[uint64]{Value: 100, Length: 4}, false)
+		conn.Write(frame)
+	}
+}
+
+func TestClientDetectProtocol(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	go serve1997(slave)
+	client := NewClient(NewMeter("", "000000013310"), master, 100*time.Millisecond)
+	version, err := client.DetectProtocol(context.Background())
+	if err != nil || version != Version1997 {
+		t.Fatalf("expected %v, got %v %v", Version1997, version, err)
+	}
+
+	//只有数据标识相同的正常应答才判定为 2007
+	answer2007 := func(reply func(req *MeterDlt645Protocol) []byte) (ProtocolVersion, error) {
+		master, slave := tcpPipe(t)
+		defer master.Close()
+		defer slave.Close()
+		answerOnce(t, slave, reply)
+		return NewClient(NewMeter("", "000000013310"), master, 100*time.Millisecond).DetectProtocol(context.Background())
+	}
+	version, err = answer2007(func(req *MeterDlt645Protocol) []byte {
+		frame, _ := BuildMasterReadResponse[uint64]("", req.Address, []byte{0x00, 0x01, 0x00, 0x00}, &MeterData[uint64]{Value: 100, Length: 4}, false)
+		return frame
+	})
+	if err != nil || version != Version2007 {
+		t.Fatalf("expected %v, got %v %v", Version2007, version, err)
+	}
+	//异常应答(D1H)或数据标识不同的应答不能确定是 2007，1997 也没有应答
+	for _, reply := range []func(req *MeterDlt645Protocol) []byte{
+		func(req *MeterDlt645Protocol) []byte {
+			frame, _ := BuildMeterAbnormalResponse("", req.Address, MeterErrNoData)
+			return frame
+		},
+		func(req *MeterDlt645Protocol) []byte {
+			frame, _ := BuildMasterReadResponse[uint64]("", req.Address, []byte{0x02, 0x01, 0x01, 0x00}, &MeterData[uint64]{Value: 2200, Length: 2}, false)
+			return frame
+		},
+	} {
+		if version, err = answer2007(reply); !errors.Is(err, ResponseTimeoutError) {
+			t.Fatalf("expected timeout, got %v %v", version, err)
+		}
+	}
+}
+
+func TestClientDetectProtocolTimeout(t *testing.T) {
+	timeout := detectTimeout
+	detectTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { detectTimeout = timeout })
+	master, slave := tcpPipe(t)
+	defer master.Close()
+	defer slave.Close()
+	//客户端没有设置超时时间，每次探测仍然有超时
+	start := time.Now()
+	if _, err := NewClient(NewMeter("", "000000013310"), master, 0).DetectProtocol(context.Background()); !errors.Is(err, ResponseTimeoutError) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Fatalf("detect took %v", d)
+	}
+}
+
+func TestClientReadNext1997(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	answerOnce(t, slave, func(req *MeterDlt645Protocol) []byte {
+		if req.ControlChar != ReadNext1997Request || !bytes.Equal(req.Data, []byte{0x10, 0x90}) {
+			return nil
+		}
+		frame, _ := (&MeterDlt645Protocol{Address: req.Address, ControlChar: ReadNext1997Response, Data: []byte{0x10, 0x90, 0x00, 0x01, 0x00, 0x00}}).Encode()
+		return frame
+	})
+	resp, err := NewClient(NewMeter("", "000000013310"), master, time.Second).ReadNext1997(context.Background(), []byte{0x90, 0x10})
+	if err != nil || resp.ControlChar != ReadNext1997Response {
+		t.Fatalf("unexpected response %+v %v", resp, err)
+	}
+}
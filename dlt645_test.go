@@ -0,0 +1,276 @@
+package go_dlt645_2007
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+var benchFrame = []byte{0xFE, 0xFE, 0xFE, 0xFE, 0x68, 0x00, 0x51, 0x44, 0x18, 0x11, 0x17, 0x68, 0x91, 0x06, 0x35, 0x33, 0xB3, 0x35, 0x36, 0x83, 0x45, 0x16}
+
+func TestEncodeDoesNotMutate(t *testing.T) {
+	data := []byte{0x00, 0x01, 0x01, 0x02}
+	m := &MeterDlt645Protocol{prefix: "FEFE", Address: "13310", ControlChar: MainStationRequestFrame, Data: data}
+	first, err := m.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := m.Encode()
+	if !bytes.Equal(first, second) {
+		t.Fatalf("encode twice: % X != % X", first, second)
+	}
+	if !bytes.Equal(data, []byte{0x00, 0x01, 0x01, 0x02}) || m.Address != "13310" {
+		t.Fatalf("encode mutated the struct: %+v", m)
+	}
+	expected := []byte{0xFE, 0xFE, 0x68, 0x10, 0x33, 0x01, 0x00, 0x00, 0x00, 0x68, 0x11, 0x04, 0x33, 0x34, 0x34, 0x35}
+	if !bytes.Equal(first[:len(expected)], expected) {
+		t.Fatalf("unexpected frame % X", first)
+	}
+	//前缀只有一部分合法时不追加任何数据
+	dst := []byte{0x01}
+	if dst, err = (&MeterDlt645Protocol{prefix: "FEFEFG", Address: "13310"}).AppendFrame(dst); err == nil || !bytes.Equal(dst, []byte{0x01}) {
+		t.Fatalf("unexpected append result % X %v", dst, err)
+	}
+	for _, address := range []string{"", "0000000133100", "00000001331G"} {
+		if _, err = (&MeterDlt645Protocol{Address: address}).Encode(); err == nil {
+			t.Fatalf("expected error for address %q", address)
+		}
+	}
+}
+
+func TestParseFrame(t *testing.T) {
+	frame := append([]byte(nil), benchFrame...)
+	m := &MeterDlt645Protocol{}
+	if err := m.ParseFrame(frame); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frame, benchFrame) {
+		t.Fatal("parse mutated the frame")
+	}
+	if m.Address != "171118445100" || m.ControlChar != 0x91 || !bytes.Equal(m.Data, []byte{0x02, 0x00, 0x80, 0x02, 0x03, 0x50}) {
+		t.Fatalf("unexpected result %+v", m)
+	}
+	if !bytes.Equal(m.Frame(), benchFrame[4:]) {
+		t.Fatalf("unexpected original % X", m.Frame())
+	}
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := m.ParseFrame(frame); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("ParseFrame allocates %v times", allocs)
+	}
+	allocs = testing.AllocsPerRun(100, func() {
+		buf := make([]byte, 0, 64)
+		if _, err := m.AppendFrame(buf); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("AppendFrame allocates %v times", allocs)
+	}
+	bad := append([]byte(nil), benchFrame...)
+	bad[len(bad)-2]++
+	if err := m.ParseFrame(bad); err != errCs {
+		t.Fatalf("expected cs error, got %v", err)
+	}
+}
+
+func TestDecodeByBuf(t *testing.T) {
+	stream := append(append([]byte{0x00, 0x11}, benchFrame...), benchFrame...)
+	reader := bufio.NewReader(bytes.NewReader(stream))
+	m := &MeterDlt645Protocol{}
+	var data [][]byte
+	for i := 0; i < 2; i++ {
+		if err := m.DecodeByBuf(reader); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(m.Frame(), benchFrame[4:]) {
+			t.Fatalf("unexpected frame % X", m.Frame())
+		}
+		data = append(data, m.Data)
+	}
+	//同一个对象再次解码不会覆盖上一次的数据域
+	data[1][0]++
+	if !bytes.Equal(data[0], []byte{0x02, 0x00, 0x80, 0x02, 0x03, 0x50}) {
+		t.Fatalf("unexpected data % X", data[0])
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	m := &MeterDlt645Protocol{prefix: "FEFEFEFE", Address: "000000013310", ControlChar: MainStationRequestFrame, Data: []byte{0x00, 0x01, 0x01, 0x02}}
+	b.ReportAllocs()
+	for b.Loop() {
+		m.Encode()
+	}
+}
+
+func BenchmarkAppendFrame(b *testing.B) {
+	m := &MeterDlt645Protocol{prefix: "FEFEFEFE", Address: "000000013310", ControlChar: MainStationRequestFrame, Data: []byte{0x00, 0x01, 0x01, 0x02}}
+	buf := make([]byte, 0, 64)
+	b.ReportAllocs()
+	for b.Loop() {
+		buf, _ = m.AppendFrame(buf[:0])
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		(&MeterDlt645Protocol{}).Decode(benchFrame)
+	}
+}
+
+// BenchmarkDecodeByBuf DecodeByBuf 每次为报文和数据域分配内存，不分配内存的解码见 BenchmarkParseFrame
+func BenchmarkDecodeByBuf(b *testing.B) {
+	reader := bytes.NewReader(benchFrame)
+	buf := bufio.NewReader(reader)
+	b.ReportAllocs()
+	for b.Loop() {
+		reader.Reset(benchFrame)
+		buf.Reset(reader)
+		(&MeterDlt645Protocol{}).DecodeByBuf(buf)
+	}
+}
+
+func BenchmarkParseFrame(b *testing.B) {
+	m := &MeterDlt645Protocol{}
+	b.ReportAllocs()
+	for b.Loop() {
+		m.ParseFrame(benchFrame)
+	}
+}
+
+// 以下是早期版本的 Encode 和 DecodeByBuf，逐字节 binary.Read 解码、每次编码都解析地址和前缀，
+// 只用于基准测试对比，例如 go test -bench 'Encode|Decode' -run '^$'
+
+func baselineEncode(m *MeterDlt645Protocol) ([]byte, error) {
+	frame := []byte{dlt645StartChar}
+	addr, err := hex.DecodeString(m.Address)
+	if err != nil {
+		return nil, err
+	}
+	frame = append(frame, reverseBytes(addr)...)
+	data := make([]byte, len(m.Data))
+	for i, b := range m.Data {
+		data[i] = b + disturb
+	}
+	frame = append(frame, dlt645StartChar, m.ControlChar, byte(len(data)))
+	frame = append(frame, data...)
+	frame = append(frame, m.cs(frame), dlt645EndChar)
+	if strings.TrimSpace(m.prefix) != "" {
+		pf, err := hex.DecodeString(m.prefix)
+		if err != nil {
+			return nil, err
+		}
+		frame = append(pf, frame...)
+	}
+	return frame, nil
+}
+
+func baselineDecodeByBuf(m *MeterDlt645Protocol, buf *bufio.Reader) error {
+	var startChar byte
+	for {
+		if err := binary.Read(buf, binary.BigEndian, &startChar); err != nil {
+			return err
+		}
+		if startChar == dlt645StartChar {
+			break
+		}
+	}
+	snap := []byte{dlt645StartChar}
+	address := make([]byte, 6)
+	if err := binary.Read(buf, binary.BigEndian, &address); err != nil {
+		return err
+	}
+	snap = append(snap, address...)
+	m.Address = hex.EncodeToString(reverseBytes(address))
+	if err := binary.Read(buf, binary.BigEndian, &startChar); err != nil {
+		return err
+	}
+	if startChar != dlt645StartChar {
+		return fmt.Errorf("start char 2 != 68H")
+	}
+	if err := binary.Read(buf, binary.BigEndian, &m.ControlChar); err != nil {
+		return err
+	}
+	if err := binary.Read(buf, binary.BigEndian, &m.Length); err != nil {
+		return err
+	}
+	snap = append(snap, dlt645StartChar, m.ControlChar, m.Length)
+	if m.Length > 0 {
+		m.Data = make([]byte, m.Length)
+		if err := binary.Read(buf, binary.BigEndian, &m.Data); err != nil {
+			return err
+		}
+		snap = append(snap, m.Data...)
+		for i, b := range m.Data {
+			m.Data[i] = b - disturb
+		}
+	}
+	var cs, endChar byte
+	if err := binary.Read(buf, binary.BigEndian, &cs); err != nil {
+		return err
+	}
+	if cs != m.cs(snap) {
+		return errors.New("cs error")
+	}
+	if err := binary.Read(buf, binary.BigEndian, &endChar); err != nil {
+		return err
+	}
+	if endChar != dlt645EndChar {
+		return fmt.Errorf("end char != 16H")
+	}
+	m.original = append(snap, cs, endChar)
+	return nil
+}
+
+func BenchmarkEncodeBaseline(b *testing.B) {
+	m := &MeterDlt645Protocol{prefix: "FEFEFEFE", Address: "000000013310", ControlChar: MainStationRequestFrame, Data: []byte{0x00, 0x01, 0x01, 0x02}}
+	b.ReportAllocs()
+	for b.Loop() {
+		baselineEncode(m)
+	}
+}
+
+// BenchmarkDecodeBaseline 早期版本的 Decode 为每帧创建 bufio.Reader 后调用 DecodeByBuf
+func BenchmarkDecodeBaseline(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		baselineDecodeByBuf(&MeterDlt645Protocol{}, bufio.NewReader(bytes.NewBuffer(benchFrame)))
+	}
+}
+
+func BenchmarkDecodeByBufBaseline(b *testing.B) {
+	reader := bytes.NewReader(benchFrame)
+	buf := bufio.NewReader(reader)
+	b.ReportAllocs()
+	for b.Loop() {
+		reader.Reset(benchFrame)
+		buf.Reset(reader)
+		baselineDecodeByBuf(&MeterDlt645Protocol{}, buf)
+	}
+}
+
+func TestBaselineDecode(t *testing.T) {
+	want, got := &MeterDlt645Protocol{}, &MeterDlt645Protocol{}
+	if err := want.Decode(benchFrame); err != nil {
+		t.Fatal(err)
+	}
+	if err := baselineDecodeByBuf(got, bufio.NewReader(bytes.NewReader(benchFrame))); err != nil {
+		t.Fatal(err)
+	}
+	if got.Address != want.Address || got.ControlChar != want.ControlChar || !bytes.Equal(got.Data, want.Data) || !bytes.Equal(got.Frame(), want.Frame()) {
+		t.Fatalf("baseline decoded %+v, want %+v", got, want)
+	}
+	frame, _ := baselineEncode(want)
+	if encoded, _ := want.Encode(); !bytes.Equal(frame, encoded) {
+		t.Fatalf("baseline encoded % X, want % X", frame, encoded)
+	}
+}
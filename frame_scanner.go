@@ -0,0 +1,160 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+const (
+	wakeupChar        byte = 0xFE //唤醒前缀
+	frameHeaderLength      = 10   //帧起始符到数据域长度的字节数
+	frameMinLength         = 12   //没有数据域时的报文长度
+	scannerReadSize        = 4096 //每次从传输层读取的字节数
+	maxEmptyReads          = 100  //传输层连续返回 0, nil 的最大次数
+)
+
+// NewFrameScanner 创建一个报文扫描器，数据域最大长度默认为200
+// reader 传输层，例如串口或 net.Conn
+func NewFrameScanner(reader io.Reader) *FrameScanner {
+	return &FrameScanner{reader: reader, maxLength: 200}
+}
+
+// FrameScanner 报文扫描器，用法与 bufio.Scanner 相同，用于噪声较大的485总线
+// 遇到第二个起始符、校验码、结束符错误或数据域过长时，从下一个候选的 68H 重新同步，不会丢失已读取的报文
+// 报文前的 FEH 唤醒前缀直接跳过，其余无法组成报文的字节计入 Discarded
+type FrameScanner struct {
+	reader    io.Reader
+	maxLength int
+	buf       []byte //未处理的数据
+	chunk     []byte //读缓冲
+	frame     *MeterDlt645Protocol
+	raw       []byte
+	discarded int64
+	err       error
+	eof       bool
+}
+
+// SetMaxLength 设置数据域的最大长度，数据域长度超过时按噪声处理，必须在 Scan 之前调用
+// length 数据域最大长度，1~255
+func (s *FrameScanner) SetMaxLength(length int) {
+	if length > 0 && length <= 255 {
+		s.maxLength = length
+	}
+}
+
+// Scan 扫描下一帧报文，成功时返回 true；传输层读到 EOF 或出错时返回 false，错误通过 Err 获取
+func (s *FrameScanner) Scan() bool {
+	s.frame, s.raw = nil, nil
+	for {
+		n, ok := s.next()
+		if ok {
+			s.raw = append([]byte(nil), s.buf[:n]...)
+			s.frame = &MeterDlt645Protocol{}
+			s.frame.Decode(s.raw)
+			s.buf = s.buf[n:]
+			return true
+		}
+		if n > 0 {
+			//候选报文无效，丢弃起始符后从下一个 68H 重新同步
+			s.discard(n)
+			continue
+		}
+		//数据不足
+		if s.eof || s.err != nil {
+			if len(s.buf) == 0 {
+				return false
+			}
+			s.discard(1)
+			continue
+		}
+		s.fill()
+	}
+}
+
+// Frame 最近一次扫描到的报文
+func (s *FrameScanner) Frame() *MeterDlt645Protocol {
+	return s.frame
+}
+
+// Bytes 最近一次扫描到的原始报文，不含唤醒前缀
+func (s *FrameScanner) Bytes() []byte {
+	return s.raw
+}
+
+// Discarded 累计丢弃的字节数，不含唤醒前缀
+func (s *FrameScanner) Discarded() int64 {
+	return s.discarded
+}
+
+// Err 传输层的错误，EOF 时返回 nil
+func (s *FrameScanner) Err() error {
+	return s.err
+}
+
+// next 在缓冲区的开头查找报文，返回 (报文长度, true) 表示找到报文，
+// (n, false) 表示开头的 n 个字节需要丢弃，(0, false) 表示需要读取更多数据
+func (s *FrameScanner) next() (int, bool) {
+	start := bytes.IndexByte(s.buf, dlt645StartChar)
+	if start < 0 {
+		return len(s.buf), false
+	}
+	if start > 0 {
+		return start, false
+	}
+	if len(s.buf) < frameHeaderLength {
+		return 0, false
+	}
+	length := int(s.buf[frameHeaderLength-1])
+	if s.buf[7] != dlt645StartChar || length > s.maxLength {
+		return 1, false
+	}
+	size := frameMinLength + length
+	if len(s.buf) < size {
+		return 0, false
+	}
+	var cs byte
+	for _, b := range s.buf[:size-2] {
+		cs += b
+	}
+	if s.buf[size-2] != cs || s.buf[size-1] != dlt645EndChar {
+		return 1, false
+	}
+	return size, true
+}
+
+// discard 丢弃缓冲区开头的 n 个字节，唤醒前缀不计入丢弃字节数
+func (s *FrameScanner) discard(n int) {
+	for _, b := range s.buf[:n] {
+		if b != wakeupChar {
+			s.discarded++
+		}
+	}
+	s.buf = s.buf[n:]
+}
+
+// fill 从传输层读取数据
+func (s *FrameScanner) fill() {
+	if len(s.buf) == 0 {
+		s.buf = nil
+	}
+	if s.chunk == nil {
+		s.chunk = make([]byte, scannerReadSize)
+	}
+	for i := 0; i < maxEmptyReads; i++ {
+		n, err := s.reader.Read(s.chunk)
+		s.buf = append(s.buf, s.chunk[:n]...)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				s.eof = true
+			} else {
+				s.err = err
+			}
+			return
+		}
+		if n > 0 {
+			return
+		}
+	}
+	s.err = io.ErrNoProgress
+}
@@ -0,0 +1,110 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFrameScanner(t *testing.T) {
+	good, _ := BuildMasterReadRequest("", "000000013310", []byte{0x02, 0x01, 0x01, 0x00}, 0, nil)
+	badCs := append([]byte(nil), good...)
+	badCs[len(badCs)-2] ^= 0xFF
+	var stream []byte
+	stream = append(stream, 0x00, 0x68, 0x11)       //噪声，含一个假的起始符
+	stream = append(stream, badCs...)               //校验码错误
+	stream = append(stream, 0xFE, 0xFE, 0xFE, 0xFE) //唤醒前缀
+	stream = append(stream, good...)                //正确报文
+	stream = append(stream, 0x68, 0x01, 0x02)       //不完整的报文
+	stream = append(stream, 0xFE, 0xFE)             //唤醒前缀
+	stream = append(stream, good...)                //正确报文
+	stream = append(stream, good[:len(good)-3]...)  //截断的报文
+
+	scanner := NewFrameScanner(iotest.OneByteReader(bytes.NewReader(stream)))
+	count := 0
+	for scanner.Scan() {
+		count++
+		if !bytes.Equal(scanner.Bytes(), good) {
+			t.Fatalf("unexpected frame % X", scanner.Bytes())
+		}
+		if scanner.Frame().ControlChar != MainStationRequestFrame || scanner.Frame().Address != "000000013310" {
+			t.Fatalf("unexpected frame %+v", scanner.Frame())
+		}
+	}
+	if scanner.Err() != nil {
+		t.Fatal(scanner.Err())
+	}
+	if count != 2 {
+		t.Fatalf("expected 2 frames, got %d", count)
+	}
+	expected := int64(3 + len(badCs) + 3 + len(good) - 3)
+	if scanner.Discarded() != expected {
+		t.Fatalf("expected %d discarded bytes, got %d", expected, scanner.Discarded())
+	}
+}
+
+func TestFrameScannerMaxLength(t *testing.T) {
+	frame, _ := (&MeterDlt645Protocol{Address: "000000013310", ControlChar: RespondingNormallyNoNext, Data: make([]byte, 20)}).Encode()
+	scanner := NewFrameScanner(bytes.NewReader(frame))
+	scanner.SetMaxLength(10)
+	if scanner.Scan() {
+		t.Fatal("expected frame longer than max length to be discarded")
+	}
+	if scanner.Discarded() != int64(len(frame)) {
+		t.Fatalf("unexpected discarded bytes %d", scanner.Discarded())
+	}
+}
+
+func TestFrameScannerError(t *testing.T) {
+	good, _ := BuildMasterReadMeterAddrRequest("")
+	reader := iotest.TimeoutReader(bytes.NewReader(append(good, good...)))
+	scanner := NewFrameScanner(reader)
+	if !scanner.Scan() || !bytes.Equal(scanner.Bytes(), good) {
+		t.Fatal("expected first frame")
+	}
+	//第一次读取已经读到全部数据，后续帧在出错后依然可以扫描
+	if !scanner.Scan() {
+		t.Fatal("expected second frame")
+	}
+	if scanner.Scan() || !errors.Is(scanner.Err(), iotest.ErrTimeout) {
+		t.Fatalf("expected timeout error, got %v", scanner.Err())
+	}
+}
+
+func FuzzFrameScanner(f *testing.F) {
+	f.Add([]byte{0x00, 0x68, 0xFE}, []byte{0x00, 0x01, 0x01, 0x02})
+	f.Add([]byte{0x68, 0x68, 0x68, 0x68, 0x68, 0x68, 0x68, 0x68, 0x68, 0xFF}, []byte{})
+	f.Add([]byte{0xFE, 0xFE, 0x16}, bytes.Repeat([]byte{0x68}, 30))
+	f.Fuzz(func(t *testing.T, garbage []byte, data []byte) {
+		//任意输入不会崩溃，扫描到的报文都能解码
+		scanner := NewFrameScanner(bytes.NewReader(append(garbage, data...)))
+		total := int64(0)
+		for scanner.Scan() {
+			if err := (&MeterDlt645Protocol{}).Decode(scanner.Bytes()); err != nil {
+				t.Fatalf("scanner returned invalid frame % X: %v", scanner.Bytes(), err)
+			}
+			total += int64(len(scanner.Bytes()))
+		}
+		if total+scanner.Discarded() > int64(len(garbage)+len(data)) {
+			t.Fatalf("scanner consumed more bytes than input")
+		}
+
+		//不含起始符的噪声之后的报文一定能扫描到
+		if len(data) > 200 {
+			data = data[:200]
+		}
+		noise := bytes.ReplaceAll(garbage, []byte{dlt645StartChar}, nil)
+		frame, err := (&MeterDlt645Protocol{Address: "000000013310", ControlChar: RespondingNormallyNoNext, Data: append([]byte(nil), data...)}).Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		scanner = NewFrameScanner(iotest.HalfReader(bytes.NewReader(append(noise, frame...))))
+		if !scanner.Scan() || !bytes.Equal(scanner.Bytes(), frame) {
+			t.Fatalf("expected frame % X after noise % X", frame, noise)
+		}
+		if expected := int64(len(noise) - bytes.Count(noise, []byte{wakeupChar})); scanner.Discarded() != expected {
+			t.Fatalf("expected %d discarded bytes, got %d", expected, scanner.Discarded())
+		}
+	})
+}
@@ -0,0 +1,222 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	loadRecordStartChar byte = 0xA0 //负荷记录起始码 A0H A0H
+	loadRecordSeparator byte = 0xAA //负荷记录块分隔码
+	loadRecordEndChar   byte = 0xE5 //负荷记录结束码 E5H E5H
+	loadChannelCount         = 6    //负荷记录类别数
+)
+
+// LoadRecordError 负荷记录格式错误
+var LoadRecordError = errors.New("dlt645_2007: load record format error")
+
+// loadValue 负荷记录中的一个数据项
+type loadValue struct {
+	size   int
+	ratio  float64
+	signed bool
+}
+
+// loadChannels 第1~6类负荷记录的数据项
+var loadChannels = [loadChannelCount][]loadValue{
+	//电压(A/B/C)、电流(A/B/C)、频率
+	{{2, 0.1, false}, {2, 0.1, false}, {2, 0.1, false}, {3, 0.001, true}, {3, 0.001, true}, {3, 0.001, true}, {2, 0.01, false}},
+	//有功功率(总/A/B/C)、无功功率(总/A/B/C)
+	{{3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}, {3, 0.0001, true}},
+	//功率因数(总/A/B/C)
+	{{2, 0.001, true}, {2, 0.001, true}, {2, 0.001, true}, {2, 0.001, true}},
+	//正向有功、反向有功、组合无功1、组合无功2总电能
+	{{4, 0.01, false}, {4, 0.01, false}, {4, 0.01, true}, {4, 0.01, true}},
+	//第一~四象限无功总电能
+	{{4, 0.01, false}, {4, 0.01, false}, {4, 0.01, false}, {4, 0.01, false}},
+	//当前有功需量、当前无功需量
+	{{3, 0.0001, true}, {3, 0.0001, true}},
+}
+
+// LoadPoint 负荷曲线上的一个点
+type LoadPoint struct {
+	Time   time.Time //负荷记录时间
+	Values []float64 //该类负荷的数据项，顺序与 DL/T 645-2007 负荷记录格式一致
+}
+
+// LoadProfile 负荷曲线，按第1~6类负荷分别保存时间序列
+type LoadProfile struct {
+	Times    []time.Time                   //所有负荷记录的时间
+	channels [loadChannelCount][]LoadPoint //第1~6类负荷
+}
+
+// Channel 获取第n类负荷的时间序列
+// n 负荷类别 1~6
+func (p *LoadProfile) Channel(n int) []LoadPoint {
+	if n < 1 || n > loadChannelCount {
+		return nil
+	}
+	return p.channels[n-1]
+}
+
+// Last 最后一条负荷记录的时间
+func (p *LoadProfile) Last() (time.Time, bool) {
+	if len(p.Times) == 0 {
+		return time.Time{}, false
+	}
+	return p.Times[len(p.Times)-1], true
+}
+
+func (p *LoadProfile) merge(other *LoadProfile, to time.Time) {
+	for _, ti := range other.Times {
+		if !ti.After(to) {
+			p.Times = append(p.Times, ti)
+		}
+	}
+	for i := range other.channels {
+		for _, point := range other.channels[i] {
+			if !point.Time.After(to) {
+				p.channels[i] = append(p.channels[i], point)
+			}
+		}
+	}
+}
+
+// DecodeLoadProfile 解析负荷记录数据块(06xxxxxx)，数据块由若干条负荷记录组成，每条记录的格式为
+// A0H A0H、记录字节数、YYMMDDhhmm、第1~6类负荷数据(每类后跟分隔码AAH)、累加校验码、E5H E5H，
+// 缺少结束码或被截断的记录返回 LoadRecordError
+// ident 数据标识，DI2为00时每条记录包含全部6类负荷，否则只包含DI2对应的一类
+// data 数据域(不含数据标识)
+// loc 时区，nil 表示 time.Local
+func DecodeLoadProfile(ident []byte, data []byte, loc *time.Location) (*LoadProfile, error) {
+	if len(ident) != 4 || ident[0] != 0x06 || ident[1] > loadChannelCount {
+		return nil, fmt.Errorf("dlt645_2007: % X is not a load record ident", ident)
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	channels := []int{0, 1, 2, 3, 4, 5}
+	if ident[1] != 0x00 {
+		channels = []int{int(ident[1]) - 1}
+	}
+	profile := &LoadProfile{}
+	for len(data) > 0 {
+		n, err := decodeLoadRecord(profile, channels, data, loc)
+		if err != nil {
+			return nil, err
+		}
+		data = data[n:]
+	}
+	return profile, nil
+}
+
+// decodeLoadRecord 解析一条负荷记录，返回这条记录的字节数
+func decodeLoadRecord(profile *LoadProfile, channels []int, data []byte, loc *time.Location) (int, error) {
+	if len(data) < 3 || data[0] != loadRecordStartChar || data[1] != loadRecordStartChar {
+		return 0, LoadRecordError
+	}
+	//记录字节数为记录时间到最后一个分隔码之间的字节数
+	length := int(data[2])
+	if len(data) < 3+length+3 || length < 5+len(channels) {
+		return 0, LoadRecordError
+	}
+	body := data[3 : 3+length]
+	var cs byte
+	for _, b := range data[:3+length] {
+		cs += b
+	}
+	if data[3+length] != cs {
+		return 0, LoadRecordError
+	}
+	if data[3+length+1] != loadRecordEndChar || data[3+length+2] != loadRecordEndChar {
+		return 0, LoadRecordError
+	}
+	ti, err := DecodeTime(FormatYYMMDDhhmm, body[:5], loc)
+	if err != nil {
+		return 0, err
+	}
+	profile.Times = append(profile.Times, ti)
+	body = body[5:]
+	for _, channel := range channels {
+		end := 0
+		for end < len(body) && body[end] != loadRecordSeparator {
+			end++
+		}
+		if end == len(body) {
+			return 0, LoadRecordError
+		}
+		//该类负荷未记录时只有分隔码
+		if end > 0 {
+			values, err := decodeLoadValues(loadChannels[channel], body[:end])
+			if err != nil {
+				return 0, err
+			}
+			profile.channels[channel] = append(profile.channels[channel], LoadPoint{Time: ti, Values: values})
+		}
+		body = body[end+1:]
+	}
+	if len(body) != 0 {
+		return 0, LoadRecordError
+	}
+	return 3 + length + 3, nil
+}
+
+func decodeLoadValues(items []loadValue, data []byte) ([]float64, error) {
+	values := make([]float64, 0, len(items))
+	for _, item := range items {
+		if len(data) < item.size {
+			return nil, LengthMismatchError
+		}
+		parser := &MeterDataParser{size: item.size, ratio: item.ratio, signed: item.signed}
+		value, err := parser.parser(data[:item.size])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value*item.ratio)
+		data = data[item.size:]
+	}
+	if len(data) != 0 {
+		return nil, LengthMismatchError
+	}
+	return values, nil
+}
+
+// ReadLoadProfile 读取给定时间范围内的负荷记录，从 from 开始按 block 块数分页读取，直到超过 to 或表计没有更多记录
+// ctx 上下文，取消时中止等待应答
+// ident 给定时间记录块的数据标识，例如 06000001
+// from 开始时间
+// to 结束时间
+// block 每次读取的负荷记录块数
+func (c *Client) ReadLoadProfile(ctx context.Context, ident []byte, from, to time.Time, block byte) (*LoadProfile, error) {
+	if block == 0 {
+		return nil, errors.New("block must be greater than 0")
+	}
+	profile := &LoadProfile{}
+	for start := from; !start.After(to); {
+		data, err := c.readAll(ctx, ident, block, &start)
+		var abnormal *AbnormalResponseError
+		if errors.As(err, &abnormal) && len(profile.Times) > 0 {
+			//已经读到记录后，表计以异常应答表示没有更多记录
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		page, err := DecodeLoadProfile(ident, data, start.Location())
+		if err != nil {
+			return nil, err
+		}
+		last, ok := page.Last()
+		if !ok || last.Before(start) {
+			break
+		}
+		profile.merge(page, to)
+		if len(page.Times) < int(block) {
+			break
+		}
+		start = last.Add(time.Minute)
+	}
+	return profile, nil
+}
@@ -0,0 +1,99 @@
+package go_dlt645_2007
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// buildLoadRecord 生成一条只记录第1类和第6类负荷的负荷记录
+func buildLoadRecord(ti time.Time) []byte {
+	body, _ := EncodeTime(FormatYYMMDDhhmm, ti)
+	//电压 220.0/221.0/222.0，电流 1.000/-2.000/3.000，频率 50.00
+	body = append(body, 0x00, 0x22, 0x10, 0x22, 0x20, 0x22, 0x00, 0x10, 0x00, 0x00, 0x20, 0x80, 0x00, 0x30, 0x00, 0x00, 0x50, loadRecordSeparator)
+	body = append(body, loadRecordSeparator, loadRecordSeparator, loadRecordSeparator, loadRecordSeparator)
+	//有功需量 1.5000，无功需量 0.2500
+	body = append(body, 0x00, 0x50, 0x01, 0x00, 0x25, 0x00, loadRecordSeparator)
+	record := append([]byte{loadRecordStartChar, loadRecordStartChar, byte(len(body))}, body...)
+	var cs byte
+	for _, b := range record {
+		cs += b
+	}
+	return append(record, cs, loadRecordEndChar, loadRecordEndChar)
+}
+
+func TestDecodeLoadProfile(t *testing.T) {
+	first := time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC)
+	data := append(buildLoadRecord(first), buildLoadRecord(first.Add(15*time.Minute))...)
+	profile, err := DecodeLoadProfile([]byte{0x06, 0x00, 0x00, 0x01}, data, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Times) != 2 || len(profile.Channel(1)) != 2 || len(profile.Channel(2)) != 0 || len(profile.Channel(6)) != 2 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+	point := profile.Channel(1)[1]
+	if !point.Time.Equal(first.Add(15*time.Minute)) || len(point.Values) != 7 {
+		t.Fatalf("unexpected point %+v", point)
+	}
+	if v := point.Values[4]; v > -1.999 || v < -2.001 {
+		t.Fatalf("unexpected current %v", v)
+	}
+	data[len(data)-3]++
+	if _, err = DecodeLoadProfile([]byte{0x06, 0x00, 0x00, 0x01}, data, time.UTC); err == nil {
+		t.Fatal("expected checksum error")
+	}
+}
+
+// loadRecord 生成一条负荷记录，sections 为第1~6类负荷数据，未记录的类别为 nil
+func loadRecord(ti time.Time, sections [loadChannelCount][]byte) []byte {
+	body, _ := EncodeTime(FormatYYMMDDhhmm, ti)
+	for _, section := range sections {
+		body = append(append(body, section...), loadRecordSeparator)
+	}
+	record := append([]byte{loadRecordStartChar, loadRecordStartChar, byte(len(body))}, body...)
+	var cs byte
+	for _, b := range record {
+		cs += b
+	}
+	return append(record, cs, loadRecordEndChar, loadRecordEndChar)
+}
+
+func TestDecodeLoadProfileBlock(t *testing.T) {
+	first := time.Date(2024, 3, 15, 13, 0, 0, 0, time.UTC)
+	//功率因数 0.950/0.960/0.970/-0.980
+	powerFactor := []byte{0x50, 0x09, 0x60, 0x09, 0x70, 0x09, 0x80, 0x89}
+	//正向有功 123.45，其余 0
+	energy := []byte{0x45, 0x23, 0x01, 0x00, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	var data []byte
+	data = append(data, buildLoadRecord(first)...)
+	data = append(data, loadRecord(first.Add(15*time.Minute), [loadChannelCount][]byte{2: powerFactor})...)
+	data = append(data, loadRecord(first.Add(30*time.Minute), [loadChannelCount][]byte{2: powerFactor, 3: energy})...)
+	data = append(data, loadRecord(first.Add(45*time.Minute), [loadChannelCount][]byte{})...)
+	ident := []byte{0x06, 0x00, 0x00, 0x01}
+	profile, err := DecodeLoadProfile(ident, data, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(profile.Times) != 4 || !profile.Times[3].Equal(first.Add(45*time.Minute)) {
+		t.Fatalf("unexpected times %v", profile.Times)
+	}
+	counts := []int{1, 0, 2, 1, 0, 1}
+	for i, count := range counts {
+		if len(profile.Channel(i+1)) != count {
+			t.Fatalf("channel %d: %d points, want %d", i+1, len(profile.Channel(i+1)), count)
+		}
+	}
+	if point := profile.Channel(3)[1]; !point.Time.Equal(first.Add(30*time.Minute)) || point.Values[3] > -0.979 || point.Values[3] < -0.981 {
+		t.Fatalf("unexpected power factor %+v", point)
+	}
+	if point := profile.Channel(4)[0]; point.Values[0] < 123.449 || point.Values[0] > 123.451 {
+		t.Fatalf("unexpected energy %+v", point)
+	}
+	//结束码只有一个 E5H，或者记录被截断
+	for _, truncated := range [][]byte{data[:len(data)-1], data[:len(data)-2], data[:len(data)-10]} {
+		if _, err = DecodeLoadProfile(ident, truncated, time.UTC); !errors.Is(err, LoadRecordError) {
+			t.Fatalf("%d bytes: expected LoadRecordError, got %v", len(truncated), err)
+		}
+	}
+}
@@ -1,16 +1,76 @@
 package go_dlt645_2007
 
-import "encoding/hex"
+import (
+	"bytes"
+	"encoding/hex"
+	"time"
+)
 
 type MasterDataReceiver interface {
-	MasterReadRequest(req *MasterReadRequestModel)                           // 主站读取数据
-	MasterReadNextRequest(ident []byte, seq byte)                            // 主站读取后续数据
-	MasterSetRequest(ident []byte, pwd []byte, operator []byte, data []byte) //主站向从站请求设置数据
-	MasterReadMeterAddrRequest()                                             //主站请求读地址
-	MasterSetMeterAddrRequest(addr string)                                   //主站设置地址
-	BroadcastTimeCalibration(ss, mm, hh, DD, MM, YY byte)                    //广播校时
-	FreezeCommand(mm, hh, DD, MM byte)                                       //冻结命令
-	ErrorData(funcCode byte, data []byte, err error)                         //解析失败的数据会调用这个方法
+	MasterReadRequest(req *MasterReadRequestModel)                             // 主站读取数据
+	MasterReadNextRequest(ident []byte, seq byte)                              // 主站读取后续数据
+	MasterSetRequest(ident []byte, pwd Password, operator []byte, data []byte) //主站向从站请求设置数据
+	MasterReadMeterAddrRequest()                                               //主站请求读地址
+	MasterSetMeterAddrRequest(addr string)                                     //主站设置地址
+	BroadcastTimeCalibration(ss, mm, hh, DD, MM, YY byte)                      //广播校时
+	FreezeCommand(mm, hh, DD, MM byte)                                         //冻结命令
+	ErrorData(funcCode byte, data []byte, err error)                           //解析失败的数据会调用这个方法
+}
+
+// 以下是 MasterDataReceiver 的可选接口，接收者没有实现时对应的请求以 FuncCodeError 调用 ErrorData
+
+// RelayControlRequestReceiver 跳合闸、报警、保电请求
+type RelayControlRequestReceiver interface {
+	// RelayControlRequest 跳合闸、报警、保电 cmd-控制命令类型，deadline-命令有效截止时间
+	RelayControlRequest(pwd Password, operator []byte, cmd RelayCommand, deadline time.Time)
+}
+
+// DemandClearRequestReceiver 最大需量清零请求
+type DemandClearRequestReceiver interface {
+	// DemandClearRequest 最大需量清零
+	DemandClearRequest(pwd Password, operator []byte)
+}
+
+// MeterClearRequestReceiver 电表清零请求
+type MeterClearRequestReceiver interface {
+	// MeterClearRequest 电表清零
+	MeterClearRequest(pwd Password, operator []byte)
+}
+
+// EventClearRequestReceiver 事件清零请求
+type EventClearRequestReceiver interface {
+	// EventClearRequest 事件清零，ident 为事件记录的数据标识，FFFFFFFF 表示清全部事件
+	EventClearRequest(ident []byte, pwd Password, operator []byte)
+}
+
+// PasswordChangeRequestReceiver 修改密码请求
+type PasswordChangeRequestReceiver interface {
+	// PasswordChangeRequest 修改密码，ident 为密码的数据标识 04000C01~04000C0A
+	PasswordChangeRequest(ident []byte, old, new Password)
+}
+
+// BaudChangeRequestReceiver 更改通信速率请求
+type BaudChangeRequestReceiver interface {
+	// BaudChangeRequest 更改通信速率，rate 为新的通信速率
+	BaudChangeRequest(rate int)
+}
+
+// SecurityRequestReceiver 安全认证请求
+type SecurityRequestReceiver interface {
+	// SecurityRequest 安全认证，data 为数据标识和操作者代码之后的数据
+	SecurityRequest(ident []byte, operator []byte, data []byte)
+}
+
+// SecureRelayControlRequestReceiver 98级或99级的跳合闸、报警、保电请求，没有实现时以控制码 RelayControlRequest 调用 ErrorData
+type SecureRelayControlRequestReceiver interface {
+	// SecureRelayControlRequest 98级或99级的跳合闸、报警、保电，payload 为安全数据，需要由安全模块校验
+	SecureRelayControlRequest(pwd Password, operator []byte, payload []byte)
+}
+
+// MultiOutputRequestReceiver 多功能端子输出控制请求
+type MultiOutputRequestReceiver interface {
+	// MultiOutputRequest 多功能端子输出控制，output 为输出信号类别
+	MultiOutputRequest(output MultiFunctionOutput)
 }
 
 func NewMasterDataCodec(receiver MasterDataReceiver) *MasterDataCodec {
@@ -18,11 +78,44 @@ func NewMasterDataCodec(receiver MasterDataReceiver) *MasterDataCodec {
 }
 
 type MasterDataCodec struct {
-	receiver MasterDataReceiver
+	receiver  MasterDataReceiver
+	passwords map[byte]Password //密码权限 -> 密码
+}
+
+// RegisterPassword 注册从站的密码，注册过密码后，带密码的请求先校验密码，校验失败时以 PasswordError 调用 ErrorData
+// 同一权限重复注册时替换原密码
+// pwd 密码
+func (m *MasterDataCodec) RegisterPassword(pwd Password) {
+	if m.passwords == nil {
+		m.passwords = make(map[byte]Password)
+	}
+	m.passwords[pwd.Level] = pwd
+}
+
+// replacePassword 修改密码成功后替换同一权限已经注册的密码，该权限没有注册过密码时不注册，返回是否替换
+// pwd 新密码
+func (m *MasterDataCodec) replacePassword(pwd Password) bool {
+	if _, ok := m.passwords[pwd.Level]; !ok {
+		return false
+	}
+	m.passwords[pwd.Level] = pwd
+	return true
+}
+
+// checkPassword 校验请求中的密码，没有注册过密码时不校验，98级和99级由接收者通过安全模块校验
+func (m *MasterDataCodec) checkPassword(funcCode byte, data []byte, pwd Password) bool {
+	if len(m.passwords) == 0 || isSecureLevel(pwd.Level) {
+		return true
+	}
+	if registered, ok := m.passwords[pwd.Level]; ok && registered == pwd {
+		return true
+	}
+	m.receiver.ErrorData(funcCode, data, PasswordError)
+	return false
 }
 
 func (m *MasterDataCodec) ParseData(funcCode byte, data []byte) {
-	if m.receiver == nil || data == nil || len(data) == 0 {
+	if m.receiver == nil {
 		return
 	}
 	switch funcCode {
@@ -40,6 +133,22 @@ func (m *MasterDataCodec) ParseData(funcCode byte, data []byte) {
 		m.parseBroadcastTimeCalibration(data)
 	case FreezeCommand: //冻结命令
 		m.parseFreezeCommand(data)
+	case RelayControlRequest: //跳合闸、报警、保电
+		m.parseRelayControlRequest(data)
+	case DemandClearRequest: //最大需量清零
+		m.parseDemandClearRequest(data)
+	case MeterClearRequest: //电表清零
+		m.parseMeterClearRequest(data)
+	case EventClearRequest: //事件清零
+		m.parseEventClearRequest(data)
+	case PasswordChangeRequest: //修改密码
+		m.parsePasswordChangeRequest(data)
+	case BaudChangeRequest: //更改通信速率
+		m.parseBaudChangeRequest(data)
+	case SecurityRequest: //安全认证
+		m.parseSecurityRequest(data)
+	case MultiOutputRequest: //多功能端子输出控制
+		m.parseMultiOutputRequest(data)
 	default:
 		m.receiver.ErrorData(funcCode, data, FuncCodeError)
 	}
@@ -73,7 +182,10 @@ func (m *MasterDataCodec) parseMasterSetRequest(data []byte) {
 		return
 	}
 	ident := data[:4]
-	pwd := data[4:8]
+	pwd, _ := PasswordFromBytes(data[4:8])
+	if !m.checkPassword(MasterSetRequest, data, pwd) {
+		return
+	}
 	operator := data[8:12]
 	data = data[12:]
 	m.receiver.MasterSetRequest(ident, pwd, operator, data)
@@ -97,10 +209,164 @@ func (m *MasterDataCodec) parseBroadcastTimeCalibration(data []byte) {
 }
 
 func (m *MasterDataCodec) parseFreezeCommand(data []byte) {
-	if len(data) < 6 {
-		m.receiver.ErrorData(BroadcastTimeCalibration, data, DataDomainError)
+	if len(data) < 4 {
+		m.receiver.ErrorData(FreezeCommand, data, DataDomainError)
 		return
 	}
 	mm, hh, DD, MM := data[0], data[1], data[2], data[3]
 	m.receiver.FreezeCommand(mm, hh, DD, MM)
 }
+
+func (m *MasterDataCodec) parseRelayControlRequest(data []byte) {
+	if len(data) >= 8 && isSecureLevel(data[0]) {
+		receiver, ok := m.receiver.(SecureRelayControlRequestReceiver)
+		if !ok {
+			m.receiver.ErrorData(RelayControlRequest, data, FuncCodeError)
+			return
+		}
+		pwd, _ := PasswordFromBytes(data[:4])
+		receiver.SecureRelayControlRequest(pwd, data[4:8], data[8:])
+		return
+	}
+	receiver, ok := m.receiver.(RelayControlRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(RelayControlRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 16 {
+		m.receiver.ErrorData(RelayControlRequest, data, DataDomainError)
+		return
+	}
+	pwd, _ := PasswordFromBytes(data[:4])
+	if !m.checkPassword(RelayControlRequest, data, pwd) {
+		return
+	}
+	operator := data[4:8]
+	cmd := RelayCommand(data[8])
+	deadline, err := DecodeTime(FormatYYMMDDhhmmss, data[10:16], time.Local)
+	if err != nil {
+		m.receiver.ErrorData(RelayControlRequest, data, err)
+		return
+	}
+	receiver.RelayControlRequest(pwd, operator, cmd, deadline)
+}
+
+func (m *MasterDataCodec) parseDemandClearRequest(data []byte) {
+	receiver, ok := m.receiver.(DemandClearRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(DemandClearRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 8 {
+		m.receiver.ErrorData(DemandClearRequest, data, DataDomainError)
+		return
+	}
+	pwd, _ := PasswordFromBytes(data[:4])
+	if !m.checkPassword(DemandClearRequest, data, pwd) {
+		return
+	}
+	receiver.DemandClearRequest(pwd, data[4:8])
+}
+
+func (m *MasterDataCodec) parseMeterClearRequest(data []byte) {
+	receiver, ok := m.receiver.(MeterClearRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(MeterClearRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 8 {
+		m.receiver.ErrorData(MeterClearRequest, data, DataDomainError)
+		return
+	}
+	pwd, _ := PasswordFromBytes(data[:4])
+	if !m.checkPassword(MeterClearRequest, data, pwd) {
+		return
+	}
+	receiver.MeterClearRequest(pwd, data[4:8])
+}
+
+func (m *MasterDataCodec) parseEventClearRequest(data []byte) {
+	receiver, ok := m.receiver.(EventClearRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(EventClearRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 12 {
+		m.receiver.ErrorData(EventClearRequest, data, DataDomainError)
+		return
+	}
+	pwd, _ := PasswordFromBytes(data[:4])
+	if !m.checkPassword(EventClearRequest, data, pwd) {
+		return
+	}
+	receiver.EventClearRequest(data[8:12], pwd, data[4:8])
+}
+
+func (m *MasterDataCodec) parsePasswordChangeRequest(data []byte) {
+	receiver, ok := m.receiver.(PasswordChangeRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(PasswordChangeRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 12 {
+		m.receiver.ErrorData(PasswordChangeRequest, data, DataDomainError)
+		return
+	}
+	ident := data[:4]
+	old, _ := PasswordFromBytes(data[4:8])
+	new, _ := PasswordFromBytes(data[8:12])
+	//数据标识与新密码的权限不符，或原密码的权限低于新密码
+	expected, err := passwordIdent(new.Level)
+	if err != nil || !bytes.Equal(reverseBytes(expected), ident) || old.Level > new.Level {
+		m.receiver.ErrorData(PasswordChangeRequest, data, PasswordError)
+		return
+	}
+	if !m.checkPassword(PasswordChangeRequest, data, old) {
+		return
+	}
+	receiver.PasswordChangeRequest(ident, old, new)
+}
+
+func (m *MasterDataCodec) parseBaudChangeRequest(data []byte) {
+	receiver, ok := m.receiver.(BaudChangeRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(BaudChangeRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 1 {
+		m.receiver.ErrorData(BaudChangeRequest, data, DataDomainError)
+		return
+	}
+	rate, err := BaudRateFromFeature(data[0])
+	if err != nil {
+		m.receiver.ErrorData(BaudChangeRequest, data, err)
+		return
+	}
+	receiver.BaudChangeRequest(rate)
+}
+
+func (m *MasterDataCodec) parseSecurityRequest(data []byte) {
+	receiver, ok := m.receiver.(SecurityRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(SecurityRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 8 {
+		m.receiver.ErrorData(SecurityRequest, data, DataDomainError)
+		return
+	}
+	receiver.SecurityRequest(data[:4], data[4:8], data[8:])
+}
+
+func (m *MasterDataCodec) parseMultiOutputRequest(data []byte) {
+	receiver, ok := m.receiver.(MultiOutputRequestReceiver)
+	if !ok {
+		m.receiver.ErrorData(MultiOutputRequest, data, FuncCodeError)
+		return
+	}
+	if len(data) < 1 || MultiFunctionOutput(data[0]) > OutputSlotSwitch {
+		m.receiver.ErrorData(MultiOutputRequest, data, DataDomainError)
+		return
+	}
+	receiver.MultiOutputRequest(MultiFunctionOutput(data[0]))
+}
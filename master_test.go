@@ -2,12 +2,23 @@ package go_dlt645_2007
 
 import (
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 )
 
 var _ MeterDataReceiver = (*TestMeterParper)(nil)
+var _ MeterValueReceiver = (*TestMeterParper)(nil)
+var _ RelayControlResponseReceiver = (*TestMeterParper)(nil)
+var _ DemandClearResponseReceiver = (*TestMeterParper)(nil)
+var _ MeterClearResponseReceiver = (*TestMeterParper)(nil)
+var _ EventClearResponseReceiver = (*TestMeterParper)(nil)
+var _ PasswordChangeResponseReceiver = (*TestMeterParper)(nil)
+var _ BaudChangeResponseReceiver = (*TestMeterParper)(nil)
+var _ SecurityResponseReceiver = (*TestMeterParper)(nil)
+var _ MultiOutputResponseReceiver = (*TestMeterParper)(nil)
 
 type TestMeterParper struct{}
 
@@ -17,16 +28,21 @@ func (t *TestMeterParper) MeterDefaultReadResponse(funcCode byte, data []byte) {
 }
 
 func (t *TestMeterParper) MeterReadResponse(ident []byte, parser *MeterDataParser, hasNext bool, seq byte) {
-	fmt.Println("MeterReadResponse", hex.EncodeToString(ident), parser.ObtainValue(), hasNext, seq)
+	value, _ := parser.ObtainValue()
+	fmt.Println("MeterReadResponse", hex.EncodeToString(ident), value, hasNext, seq)
 
 }
 
-func (t *TestMeterParper) MeterReadErrorResponse(funcCode byte, errCode byte) {
+func (t *TestMeterParper) MeterReadValue(ident []byte, value any, hasNext bool, seq byte) {
+	fmt.Println("MeterReadValue", hex.EncodeToString(ident), value, hasNext, seq)
+}
+
+func (t *TestMeterParper) MeterReadErrorResponse(funcCode byte, errCode MeterError) {
 	//TODO implement me
 	panic("implement me")
 }
 
-func (t *TestMeterParper) MeterReqMasterSet(isSuccess bool, errCode byte) {
+func (t *TestMeterParper) MeterReqMasterSet(isSuccess bool, errCode MeterError) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -36,7 +52,47 @@ func (t *TestMeterParper) MeterAddress(addr string) {
 	panic("implement me")
 }
 
-func (t *TestMeterParper) FreezeCommandResponse(isSuccess bool, errCode byte) {
+func (t *TestMeterParper) FreezeCommandResponse(isSuccess bool, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) RelayControlResponse(isSuccess bool, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) DemandClearResponse(isSuccess bool, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) MeterClearResponse(isSuccess bool, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) EventClearResponse(isSuccess bool, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) PasswordChangeResponse(isSuccess bool, pwd Password, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) BaudChangeResponse(isSuccess bool, rate int, errCode MeterError) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) SecurityResponse(isSuccess bool, ident []byte, data []byte, serr uint16) {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (t *TestMeterParper) MultiOutputResponse(isSuccess bool, output MultiFunctionOutput, errCode MeterError) {
 	//TODO implement me
 	panic("implement me")
 }
@@ -79,3 +135,96 @@ func TestMaster(t *testing.T) {
 	}
 	codec.ParseData(pro.ControlChar, pro.Data)
 }
+
+// bareMeterReceiver 只实现 MeterDataReceiver，没有实现任何可选接口
+type bareMeterReceiver struct {
+	MeterDataReceiver
+	err error
+}
+
+func (r *bareMeterReceiver) ErrorData(funcCode byte, data []byte, err error) {
+	r.err = err
+}
+
+// bareMasterReceiver 只实现 MasterDataReceiver，没有实现任何可选接口
+type bareMasterReceiver struct {
+	MasterDataReceiver
+	err error
+}
+
+func (r *bareMasterReceiver) ErrorData(funcCode byte, data []byte, err error) {
+	r.err = err
+}
+
+// TestOptionalReceivers 接收者没有实现可选接口时，对应的报文以 FuncCodeError 调用 ErrorData
+func TestOptionalReceivers(t *testing.T) {
+	pwd, operator := Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}
+	cases := []struct {
+		name  string
+		build func() ([]byte, error)
+	}{
+		{"relay control request", func() ([]byte, error) {
+			return BuildRelayControlRequest("", "000000013310", pwd, operator, RelayTrip, time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local))
+		}},
+		{"relay control response", func() ([]byte, error) { return BuildRelayControlResponse("", "000000013310") }},
+		{"demand clear request", func() ([]byte, error) { return BuildDemandClearRequest("", "000000013310", pwd, operator) }},
+		{"demand clear response", func() ([]byte, error) { return BuildDemandClearResponse("", "000000013310") }},
+		{"meter clear request", func() ([]byte, error) { return BuildMeterClearRequest("", "000000013310", pwd, operator) }},
+		{"meter clear error response", func() ([]byte, error) {
+			return BuildMeterClearErrResponse("", "000000013310", MeterErrUnauthorized)
+		}},
+		{"event clear request", func() ([]byte, error) {
+			return BuildEventClearRequest("", "000000013310", []byte{0xFF, 0xFF, 0xFF, 0xFF}, pwd, operator)
+		}},
+		{"event clear response", func() ([]byte, error) { return BuildEventClearResponse("", "000000013310") }},
+		{"password change request", func() ([]byte, error) {
+			return BuildPasswordChangeRequest("", "000000013310", pwd, Password{Level: PasswordLevel04})
+		}},
+		{"password change response", func() ([]byte, error) {
+			return BuildPasswordChangeResponse("", "000000013310", Password{Level: PasswordLevel04})
+		}},
+		{"password change error response", func() ([]byte, error) {
+			return BuildPasswordChangeErrResponse("", "000000013310", MeterErrUnauthorized)
+		}},
+		{"baud change request", func() ([]byte, error) { return BuildBaudChangeRequest("", "000000013310", 9600) }},
+		{"baud change response", func() ([]byte, error) { return BuildBaudChangeResponse("", "000000013310", 9600) }},
+		{"security request", func() ([]byte, error) {
+			return BuildSecurityRequest("", "000000013310", []byte{0x07, 0x00, 0x00, 0xFF}, operator, nil)
+		}},
+		{"secure relay control request", func() ([]byte, error) {
+			module, _ := NewSoftwareSecurityModule(make([]byte, 16))
+			return BuildSecureRelayControlRequest("", "000000013310", operator, RelayTrip, time.Date(2030, 1, 1, 0, 0, 0, 0, time.Local), module)
+		}},
+		{"security response", func() ([]byte, error) {
+			return BuildSecurityResponse("", "000000013310", []byte{0x07, 0x00, 0x00, 0xFF}, nil)
+		}},
+		{"security error response", func() ([]byte, error) { return BuildSecurityErrResponse("", "000000013310", 0x0004) }},
+		{"multi output request", func() ([]byte, error) { return BuildMultiOutputRequest("", "000000013310", OutputSlotSwitch) }},
+		{"multi output response", func() ([]byte, error) { return BuildMultiOutputResponse("", "000000013310", OutputSlotSwitch) }},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			frame, err := c.build()
+			if err != nil {
+				t.Fatal(err)
+			}
+			pro := &MeterDlt645Protocol{}
+			if err = pro.Decode(frame); err != nil {
+				t.Fatal(err)
+			}
+			var got error
+			if pro.ControlChar&0x80 == 0 {
+				receiver := &bareMasterReceiver{}
+				NewMasterDataCodec(receiver).ParseData(pro.ControlChar, pro.Data)
+				got = receiver.err
+			} else {
+				receiver := &bareMeterReceiver{}
+				NewMeterDataCodec(receiver).ParseData(pro.ControlChar, pro.Data)
+				got = receiver.err
+			}
+			if !errors.Is(got, FuncCodeError) {
+				t.Fatalf("expected FuncCodeError, got %v", got)
+			}
+		})
+	}
+}
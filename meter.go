@@ -55,7 +55,7 @@ func (m *Meter) BuildMasterReadResponse(ident []byte, value interface{}, valueLe
 
 // BuildMeterAbnormalResponse 创建一个读数据/主站请求帧的从站异常应答
 // errCode 错误码
-func (m *Meter) BuildMeterAbnormalResponse(errCode byte) ([]byte, error) {
+func (m *Meter) BuildMeterAbnormalResponse(errCode MeterError) ([]byte, error) {
 	return BuildMeterAbnormalResponse(m.prefix, m.address, errCode)
 }
 
@@ -95,7 +95,7 @@ func (m *Meter) BuildMeterReadNextDataResponse(ident []byte, value interface{},
 
 // BuildMeterReadNextErrResponse 从站异常回复后续帧的应答
 // errCode 错误码
-func (m *Meter) BuildMeterReadNextErrResponse(errCode byte) ([]byte, error) {
+func (m *Meter) BuildMeterReadNextErrResponse(errCode MeterError) ([]byte, error) {
 	return BuildMeterReadNextErrResponse(m.prefix, m.address, errCode)
 }
 
@@ -107,7 +107,7 @@ func (m *Meter) BuildMeterReadNextErrResponse(errCode byte) ([]byte, error) {
 // operatorCode 操作者代码
 // value 设定值
 // valueLength 数据长度
-func (m *Meter) BuildMasterSetRequest(ident, pwd, operatorCode []byte, value interface{}, valueLength byte) ([]byte, error) {
+func (m *Meter) BuildMasterSetRequest(ident []byte, pwd Password, operatorCode []byte, value interface{}, valueLength byte) ([]byte, error) {
 	switch v := value.(type) {
 	case int64:
 		return BuildMasterSetRequest[int64](m.prefix, m.address, ident, pwd, operatorCode, &MeterData[int64]{Value: v, Length: valueLength})
@@ -135,7 +135,7 @@ func (m *Meter) BuildMeterSetResponse() ([]byte, error) {
 
 // BuildMeterSetErrResponse 构建一个回复主站向从站请求设置数据(或编程)的异常应答报文
 // errorCode 错误码
-func (m *Meter) BuildMeterSetErrResponse(errorCode byte) ([]byte, error) {
+func (m *Meter) BuildMeterSetErrResponse(errorCode MeterError) ([]byte, error) {
 	return BuildMeterSetErrResponse(m.prefix, m.address, errorCode)
 }
 
@@ -153,3 +153,219 @@ func (m *Meter) BuildMasterSetMeterAddrRequest() ([]byte, error) {
 func (m *Meter) BuildMeterSetMeterAddrResponse() ([]byte, error) {
 	return BuildMeterSetMeterAddrResponse(m.prefix, m.address)
 }
+
+// BuildRelayControlRequest 构建一个跳合闸、报警、保电的请求报文
+// pwd 密码
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+func (m *Meter) BuildRelayControlRequest(pwd Password, operatorCode []byte, cmd RelayCommand, deadline time.Time) ([]byte, error) {
+	return BuildRelayControlRequest(m.prefix, m.address, pwd, operatorCode, cmd, deadline)
+}
+
+// BuildRelayControlResponse 构建一个跳合闸、报警、保电的正常应答报文
+func (m *Meter) BuildRelayControlResponse() ([]byte, error) {
+	return BuildRelayControlResponse(m.prefix, m.address)
+}
+
+// BuildRelayControlErrResponse 构建一个跳合闸、报警、保电的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildRelayControlErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildRelayControlErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildDemandClearRequest 构建一个最大需量清零的请求报文
+// pwd 密码
+// operatorCode 操作者代码
+func (m *Meter) BuildDemandClearRequest(pwd Password, operatorCode []byte) ([]byte, error) {
+	return BuildDemandClearRequest(m.prefix, m.address, pwd, operatorCode)
+}
+
+// BuildDemandClearResponse 构建一个最大需量清零的正常应答报文
+func (m *Meter) BuildDemandClearResponse() ([]byte, error) {
+	return BuildDemandClearResponse(m.prefix, m.address)
+}
+
+// BuildDemandClearErrResponse 构建一个最大需量清零的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildDemandClearErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildDemandClearErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildMeterClearRequest 构建一个电表清零的请求报文
+// pwd 密码
+// operatorCode 操作者代码
+func (m *Meter) BuildMeterClearRequest(pwd Password, operatorCode []byte) ([]byte, error) {
+	return BuildMeterClearRequest(m.prefix, m.address, pwd, operatorCode)
+}
+
+// BuildMeterClearResponse 构建一个电表清零的正常应答报文
+func (m *Meter) BuildMeterClearResponse() ([]byte, error) {
+	return BuildMeterClearResponse(m.prefix, m.address)
+}
+
+// BuildMeterClearErrResponse 构建一个电表清零的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildMeterClearErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildMeterClearErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildEventClearRequest 构建一个事件清零的请求报文
+// ident 事件记录的数据标识，FFFFFFFF 表示清全部事件
+// pwd 密码
+// operatorCode 操作者代码
+func (m *Meter) BuildEventClearRequest(ident []byte, pwd Password, operatorCode []byte) ([]byte, error) {
+	return BuildEventClearRequest(m.prefix, m.address, ident, pwd, operatorCode)
+}
+
+// BuildEventClearResponse 构建一个事件清零的正常应答报文
+func (m *Meter) BuildEventClearResponse() ([]byte, error) {
+	return BuildEventClearResponse(m.prefix, m.address)
+}
+
+// BuildEventClearErrResponse 构建一个事件清零的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildEventClearErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildEventClearErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildPasswordChangeRequest 构建一个修改密码的请求报文
+// old 原密码
+// new 新密码
+func (m *Meter) BuildPasswordChangeRequest(old, new Password) ([]byte, error) {
+	return BuildPasswordChangeRequest(m.prefix, m.address, old, new)
+}
+
+// BuildPasswordChangeResponse 构建一个修改密码的正常应答报文
+// pwd 新密码
+func (m *Meter) BuildPasswordChangeResponse(pwd Password) ([]byte, error) {
+	return BuildPasswordChangeResponse(m.prefix, m.address, pwd)
+}
+
+// BuildPasswordChangeErrResponse 构建一个修改密码的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildPasswordChangeErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildPasswordChangeErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildBaudChangeRequest 构建一个更改通信速率的请求报文
+// rate 新的通信速率
+func (m *Meter) BuildBaudChangeRequest(rate int) ([]byte, error) {
+	return BuildBaudChangeRequest(m.prefix, m.address, rate)
+}
+
+// BuildBaudChangeResponse 构建一个更改通信速率的正常应答报文
+// rate 变更后的通信速率
+func (m *Meter) BuildBaudChangeResponse(rate int) ([]byte, error) {
+	return BuildBaudChangeResponse(m.prefix, m.address, rate)
+}
+
+// BuildBaudChangeErrResponse 构建一个更改通信速率的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildBaudChangeErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildBaudChangeErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildSecurityRequest 构建一个安全认证的请求报文
+// ident 数据标识
+// operatorCode 操作者代码
+// data 数据标识和操作者代码之后的数据
+func (m *Meter) BuildSecurityRequest(ident, operatorCode, data []byte) ([]byte, error) {
+	return BuildSecurityRequest(m.prefix, m.address, ident, operatorCode, data)
+}
+
+// BuildIdentityAuthRequest 构建一个身份认证的请求报文，返回报文和随机数1
+// operatorCode 操作者代码
+// module 安全模块
+// factor 分散因子，8字节
+func (m *Meter) BuildIdentityAuthRequest(operatorCode []byte, module SecurityModule, factor []byte) ([]byte, []byte, error) {
+	return BuildIdentityAuthRequest(m.prefix, m.address, operatorCode, module, factor)
+}
+
+// BuildSecurityResponse 构建一个安全认证的正常应答报文
+// ident 数据标识
+// data 数据标识之后的数据
+func (m *Meter) BuildSecurityResponse(ident, data []byte) ([]byte, error) {
+	return BuildSecurityResponse(m.prefix, m.address, ident, data)
+}
+
+// BuildSecurityErrResponse 构建一个安全认证的异常应答报文
+// serr 安全认证错误信息字
+func (m *Meter) BuildSecurityErrResponse(serr uint16) ([]byte, error) {
+	return BuildSecurityErrResponse(m.prefix, m.address, serr)
+}
+
+// BuildSecureSetRequest 构建一个98级或99级写数据的请求报文
+// ident 数据标识
+// level 密码权限，98 或 99
+// operatorCode 操作者代码
+// value 数据，低字节在前
+// module 安全模块
+func (m *Meter) BuildSecureSetRequest(ident []byte, level byte, operatorCode, value []byte, module SecurityModule) ([]byte, error) {
+	return BuildSecureSetRequest(m.prefix, m.address, ident, level, operatorCode, value, module)
+}
+
+// BuildSecureRelayControlRequest 构建一个98级跳合闸、报警、保电的请求报文
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+// module 安全模块
+func (m *Meter) BuildSecureRelayControlRequest(operatorCode []byte, cmd RelayCommand, deadline time.Time, module SecurityModule) ([]byte, error) {
+	return BuildSecureRelayControlRequest(m.prefix, m.address, operatorCode, cmd, deadline, module)
+}
+
+// BuildMultiOutputRequest 构建一个多功能端子输出控制的请求报文
+// output 输出信号类别
+func (m *Meter) BuildMultiOutputRequest(output MultiFunctionOutput) ([]byte, error) {
+	return BuildMultiOutputRequest(m.prefix, m.address, output)
+}
+
+// BuildMultiOutputResponse 构建一个多功能端子输出控制的正常应答报文
+// output 输出信号类别
+func (m *Meter) BuildMultiOutputResponse(output MultiFunctionOutput) ([]byte, error) {
+	return BuildMultiOutputResponse(m.prefix, m.address, output)
+}
+
+// BuildMultiOutputErrResponse 构建一个多功能端子输出控制的异常应答报文
+// errCode 错误码
+func (m *Meter) BuildMultiOutputErrResponse(errCode MeterError) ([]byte, error) {
+	return BuildMultiOutputErrResponse(m.prefix, m.address, errCode)
+}
+
+// BuildMasterReadRequest1997 创建一个 1997 读数据请求帧
+// ident 数据标识，DI1 在前
+func (m *Meter) BuildMasterReadRequest1997(ident []byte) ([]byte, error) {
+	return BuildMasterReadRequest1997(m.prefix, m.address, ident)
+}
+
+// BuildMasterReadNextRequest1997 创建一个 1997 读后续数据请求帧
+// ident 数据标识，DI1 在前
+func (m *Meter) BuildMasterReadNextRequest1997(ident []byte) ([]byte, error) {
+	return BuildMasterReadNextRequest1997(m.prefix, m.address, ident)
+}
+
+// BuildMasterSetRequest1997 创建一个 1997 写数据请求帧
+// ident 数据标识，DI1 在前
+// pwd 密码
+// value 设定值
+// valueLength 数据长度
+func (m *Meter) BuildMasterSetRequest1997(ident []byte, pwd Password, value interface{}, valueLength byte) ([]byte, error) {
+	switch v := value.(type) {
+	case int64:
+		return BuildMasterSetRequest1997[int64](m.prefix, m.address, ident, pwd, &MeterData[int64]{Value: v, Length: valueLength})
+	case uint64:
+		return BuildMasterSetRequest1997[uint64](m.prefix, m.address, ident, pwd, &MeterData[uint64]{Value: v, Length: valueLength})
+	case string:
+		return BuildMasterSetRequest1997[string](m.prefix, m.address, ident, pwd, &MeterData[string]{Value: v, Length: valueLength})
+	case []byte:
+		return BuildMasterSetRequest1997[[]byte](m.prefix, m.address, ident, pwd, &MeterData[[]byte]{Value: v, Length: valueLength})
+	case []int64:
+		return BuildMasterSetRequest1997[[]int64](m.prefix, m.address, ident, pwd, &MeterData[[]int64]{Value: v, Length: valueLength})
+	case []uint64:
+		return BuildMasterSetRequest1997[[]uint64](m.prefix, m.address, ident, pwd, &MeterData[[]uint64]{Value: v, Length: valueLength})
+	case []string:
+		return BuildMasterSetRequest1997[[]string](m.prefix, m.address, ident, pwd, &MeterData[[]string]{Value: v, Length: valueLength})
+	default:
+		return nil, errors.New("invalid type")
+	}
+}
@@ -3,6 +3,7 @@ package go_dlt645_2007
 import (
 	"encoding/hex"
 	"errors"
+	"time"
 )
 
 // FuncCodeError 功能码错误或未实现这个功能码的逻辑
@@ -16,22 +17,79 @@ type MeterDataReceiver interface {
 	MeterReadResponse(ident []byte, parser *MeterDataParser, hasNext bool, seq byte)
 	MeterDefaultReadResponse(funcCode byte, data []byte) //MeterReadResponse 找不到注册器就会到这里
 	// MeterReadErrorResponse 读数据后电表的异常应答，reqFrame-请求的报文， funcCode-控制码，errCode-错误信息字
-	MeterReadErrorResponse(funcCode byte, errCode byte)
-	MeterReqMasterSet(isSuccess bool, errCode byte)     //设置电表后的回复
-	MeterAddress(addr string)                           //读电表地址的回复
-	FreezeCommandResponse(isSuccess bool, errCode byte) //冻结命令回复
+	MeterReadErrorResponse(funcCode byte, errCode MeterError)
+	MeterReqMasterSet(isSuccess bool, errCode MeterError)     //设置电表后的回复
+	MeterAddress(addr string)                                 //读电表地址的回复
+	FreezeCommandResponse(isSuccess bool, errCode MeterError) //冻结命令回复
 	// ErrorData 解析失败的数据会调用这个方法 funcCode-控制码， data-数据域， err-错误类型
 	ErrorData(funcCode byte, data []byte, err error)
 }
 
+// MeterValueReceiver MeterDataReceiver 的可选接口，接收者实现了这个接口时，RegisterDecoder 注册的解码器的解析结果通过 MeterReadValue 返回，
+// 否则按没有注册解析器处理，调用 MeterDefaultReadResponse
+type MeterValueReceiver interface {
+	// MeterReadValue 通过 RegisterDecoder 注册的解码器解析的数据 ident-数据标识，value-解码结果，hasNext是否存在后续帧, seq-帧序号
+	MeterReadValue(ident []byte, value any, hasNext bool, seq byte)
+}
+
+// 以下是 MeterDataReceiver 的其他可选接口，接收者没有实现时对应的应答以 FuncCodeError 调用 ErrorData
+
+// RelayControlResponseReceiver 跳合闸、报警、保电应答
+type RelayControlResponseReceiver interface {
+	RelayControlResponse(isSuccess bool, errCode MeterError) //跳合闸、报警、保电回复
+}
+
+// DemandClearResponseReceiver 最大需量清零应答
+type DemandClearResponseReceiver interface {
+	DemandClearResponse(isSuccess bool, errCode MeterError) //最大需量清零回复
+}
+
+// MeterClearResponseReceiver 电表清零应答
+type MeterClearResponseReceiver interface {
+	MeterClearResponse(isSuccess bool, errCode MeterError) //电表清零回复
+}
+
+// EventClearResponseReceiver 事件清零应答
+type EventClearResponseReceiver interface {
+	EventClearResponse(isSuccess bool, errCode MeterError) //事件清零回复
+}
+
+// PasswordChangeResponseReceiver 修改密码应答
+type PasswordChangeResponseReceiver interface {
+	// PasswordChangeResponse 修改密码回复，成功时 pwd 为表计应答的新密码
+	PasswordChangeResponse(isSuccess bool, pwd Password, errCode MeterError)
+}
+
+// BaudChangeResponseReceiver 更改通信速率应答
+type BaudChangeResponseReceiver interface {
+	// BaudChangeResponse 更改通信速率回复，成功时 rate 为变更后的通信速率
+	BaudChangeResponse(isSuccess bool, rate int, errCode MeterError)
+}
+
+// SecurityResponseReceiver 安全认证应答
+type SecurityResponseReceiver interface {
+	// SecurityResponse 安全认证回复，成功时 ident 为数据标识、data 为数据标识之后的数据，失败时 serr 为安全认证错误信息字
+	SecurityResponse(isSuccess bool, ident []byte, data []byte, serr uint16)
+}
+
+// MultiOutputResponseReceiver 多功能端子输出控制应答
+type MultiOutputResponseReceiver interface {
+	// MultiOutputResponse 多功能端子输出控制回复，成功时 output 为表计应答的输出信号类别
+	MultiOutputResponse(isSuccess bool, output MultiFunctionOutput, errCode MeterError)
+}
+
 func NewMeterDataCodec(receiver MeterDataReceiver) *MeterDataCodec {
-	return &MeterDataCodec{receiver: receiver, parsers: make(map[string]*MeterDataParser)}
+	valueReceiver, _ := receiver.(MeterValueReceiver)
+	return &MeterDataCodec{receiver: receiver, valueReceiver: valueReceiver, parsers: make(map[string]*MeterDataParser), decoders: make(map[string]DataDecoder)}
 }
 
 // MeterDataCodec 数据解析器
 type MeterDataCodec struct {
-	receiver MeterDataReceiver
-	parsers  map[string]*MeterDataParser
+	receiver      MeterDataReceiver
+	valueReceiver MeterValueReceiver //接收者没有实现 MeterValueReceiver 时为 nil
+	parsers       map[string]*MeterDataParser
+	decoders      map[string]DataDecoder
+	catalogue     bool //未注册的数据标识是否从数据标识目录自动创建解析器
 }
 
 // Register 注册数据解析器
@@ -41,27 +99,129 @@ func (m *MeterDataCodec) Register(ident []byte, parser *MeterDataParser) {
 	m.parsers[hex.EncodeToString(reverseBytes(ident))] = parser
 }
 
+// RegisterDecoder 注册数据域解码器，用于日期时间、复合记录等不能用 MeterDataParser 解析的数据项，
+// 解码结果通过 MeterValueReceiver.MeterReadValue 返回
+// ident 数据标识
+// decoder 数据域解码器
+func (m *MeterDataCodec) RegisterDecoder(ident []byte, decoder DataDecoder) {
+	m.decoders[hex.EncodeToString(reverseBytes(ident))] = decoder
+}
+
+// RegisterCatalogue 启用数据标识目录，未注册解析器的数据标识按目录中的数据格式自动注册解析器
+func (m *MeterDataCodec) RegisterCatalogue() {
+	m.catalogue = true
+}
+
+// obtainParser 获取数据解析器
+// ident 报文中的数据标识，低字节在前
+func (m *MeterDataCodec) obtainParser(ident []byte) (*MeterDataParser, bool) {
+	key := hex.EncodeToString(ident)
+	if parser, ok := m.parsers[key]; ok {
+		return parser, true
+	}
+	if !m.catalogue {
+		return nil, false
+	}
+	info, ok := LookupDataIdent(reverseBytes(ident))
+	if !ok {
+		return nil, false
+	}
+	parser, err := info.Parser()
+	if err != nil {
+		return nil, false
+	}
+	m.parsers[key] = parser
+	return parser, true
+}
+
+// obtainDecoder 获取 RegisterDecoder 注册的数据域解码器
+// ident 报文中的数据标识，低字节在前
+func (m *MeterDataCodec) obtainDecoder(ident []byte) (DataDecoder, bool) {
+	key := hex.EncodeToString(ident)
+	if decoder, ok := m.decoders[key]; ok {
+		return decoder, true
+	}
+	if !m.catalogue {
+		return nil, false
+	}
+	info, ok := LookupDataIdent(reverseBytes(ident))
+	if !ok {
+		return nil, false
+	}
+	decoder, err := info.Decoder(time.Local)
+	if err != nil {
+		return nil, false
+	}
+	m.decoders[key] = decoder
+	return decoder, true
+}
+
+// decode 使用注册的解析器或解码器解析合并后的数据域，都没有注册时返回原始数据域
+// ident 数据标识
+// data 数据域，不含数据标识
+func (m *MeterDataCodec) decode(ident []byte, data []byte) (any, error) {
+	if parser, ok := m.obtainParser(reverseBytes(ident)); ok {
+		return parser.Decode(data)
+	}
+	if decoder, ok := m.obtainDecoder(reverseBytes(ident)); ok {
+		return decoder.Decode(data)
+	}
+	return data, nil
+}
+
 func (m *MeterDataCodec) ParseData(funcCode byte, data []byte) {
-	if m.receiver == nil || data == nil || len(data) == 0 {
+	if m.receiver == nil {
 		return
 	}
 	switch funcCode {
 	case RespondingNormallyNoNext, RespondingNormallyHasNext: //从站正常应答
 		m.parseRespondingNormally(funcCode, data)
 	case SlaveErrResponse: //从站异常应答
-		m.receiver.MeterReadErrorResponse(funcCode, data[0])
+		m.receiver.MeterReadErrorResponse(funcCode, obtainErrCode(data))
 	case NextRespondingNormallyNoNext, NextRespondingNormallyHasNext: //从站正常应答， 无后续帧,从站正常应答， 有后续帧
 		m.parserReadNextResponse(funcCode, data)
 	case NextSlaveErrResponse: //从站异常应答
-		m.receiver.MeterReadErrorResponse(funcCode, data[0])
+		m.receiver.MeterReadErrorResponse(funcCode, obtainErrCode(data))
 	case MeterSetResponse, MeterSetErrResponse: //主站设置，从站正常/异常应答
-		m.receiver.MeterReqMasterSet(funcCode == MeterSetResponse, data[0])
+		m.receiver.MeterReqMasterSet(funcCode == MeterSetResponse, obtainErrCode(data))
 	case MeterAddrResponse:
 		m.parserMeterAddrResponse(data)
 	case MeterSetMeterAddrResponse:
 		m.parserSetAddrResponse(data)
 	case FreezeCommandResponse, FreezeCommandErrorResponse:
-		m.receiver.FreezeCommandResponse(funcCode == FreezeCommandResponse, data[0])
+		m.receiver.FreezeCommandResponse(funcCode == FreezeCommandResponse, obtainErrCode(data))
+	case RelayControlResponse, RelayControlErrResponse:
+		if receiver, ok := m.receiver.(RelayControlResponseReceiver); ok {
+			receiver.RelayControlResponse(funcCode == RelayControlResponse, obtainErrCode(data))
+		} else {
+			m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		}
+	case DemandClearResponse, DemandClearErrResponse:
+		if receiver, ok := m.receiver.(DemandClearResponseReceiver); ok {
+			receiver.DemandClearResponse(funcCode == DemandClearResponse, obtainErrCode(data))
+		} else {
+			m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		}
+	case MeterClearResponse, MeterClearErrResponse:
+		if receiver, ok := m.receiver.(MeterClearResponseReceiver); ok {
+			receiver.MeterClearResponse(funcCode == MeterClearResponse, obtainErrCode(data))
+		} else {
+			m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		}
+	case EventClearResponse, EventClearErrResponse:
+		if receiver, ok := m.receiver.(EventClearResponseReceiver); ok {
+			receiver.EventClearResponse(funcCode == EventClearResponse, obtainErrCode(data))
+		} else {
+			m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		}
+	case PasswordChangeResponse, PasswordChangeErrResponse:
+		m.parsePasswordChangeResponse(funcCode, data)
+	case BaudChangeResponse, BaudChangeErrResponse:
+		m.parseBaudChangeResponse(funcCode, data)
+	case SecurityResponse, SecurityErrResponse:
+		m.parseSecurityResponse(funcCode, data)
+	case MultiOutputResponse, MultiOutputErrResponse:
+		m.parseMultiOutputResponse(funcCode, data)
 	default:
 		m.receiver.ErrorData(funcCode, data, FuncCodeError)
 	}
@@ -77,7 +237,7 @@ func (m *MeterDataCodec) parseRespondingNormally(funcCode byte, data []byte) {
 	//解析数据
 	ident := data[:4]
 	//判断是否存在数据解析器
-	if parser, ok := m.parsers[hex.EncodeToString(ident)]; ok {
+	if parser, ok := m.obtainParser(ident); ok {
 		parser.flush()
 		if len(data) == 4 {
 			m.receiver.MeterReadResponse(reverseBytes(ident), nil, hasNext, 0)
@@ -89,6 +249,17 @@ func (m *MeterDataCodec) parseRespondingNormally(funcCode byte, data []byte) {
 			return
 		}
 		m.receiver.MeterReadResponse(reverseBytes(ident), parser, hasNext, 0)
+	} else if decoder, ok := m.obtainDecoder(ident); ok && m.valueReceiver != nil {
+		if len(data) == 4 {
+			m.valueReceiver.MeterReadValue(reverseBytes(ident), nil, hasNext, 0)
+			return
+		}
+		value, err := decoder.Decode(data[4:])
+		if err != nil {
+			m.receiver.ErrorData(funcCode, data, err)
+			return
+		}
+		m.valueReceiver.MeterReadValue(reverseBytes(ident), value, hasNext, 0)
 	} else {
 		m.receiver.MeterDefaultReadResponse(funcCode, data)
 	}
@@ -102,13 +273,20 @@ func (m *MeterDataCodec) parserReadNextResponse(funcCode byte, data []byte) {
 	hasNext := funcCode == NextRespondingNormallyHasNext
 	ident := data[:4]
 	//判断是否存在数据解析器
-	if parser, ok := m.parsers[hex.EncodeToString(ident)]; ok {
+	if parser, ok := m.obtainParser(ident); ok {
 		err := parser.decode(data[4 : len(data)-1])
 		if err != nil {
 			m.receiver.ErrorData(funcCode, data, err)
 			return
 		}
 		m.receiver.MeterReadResponse(reverseBytes(ident), parser, hasNext, data[len(data)-1])
+	} else if decoder, ok := m.obtainDecoder(ident); ok && m.valueReceiver != nil {
+		value, err := decoder.Decode(data[4 : len(data)-1])
+		if err != nil {
+			m.receiver.ErrorData(funcCode, data, err)
+			return
+		}
+		m.valueReceiver.MeterReadValue(reverseBytes(ident), value, hasNext, data[len(data)-1])
 	} else {
 		m.receiver.MeterDefaultReadResponse(funcCode, data)
 	}
@@ -129,3 +307,89 @@ func (m *MeterDataCodec) parserSetAddrResponse(data []byte) {
 	}
 	m.receiver.MeterAddress(hex.EncodeToString(reverseBytes(data)))
 }
+
+// obtainErrCode 获取错误信息字，正常应答没有数据域时为0
+func obtainErrCode(data []byte) MeterError {
+	if len(data) == 0 {
+		return 0
+	}
+	return MeterError(data[0])
+}
+
+func (m *MeterDataCodec) parsePasswordChangeResponse(funcCode byte, data []byte) {
+	receiver, ok := m.receiver.(PasswordChangeResponseReceiver)
+	if !ok {
+		m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		return
+	}
+	if funcCode == PasswordChangeErrResponse {
+		receiver.PasswordChangeResponse(false, Password{}, obtainErrCode(data))
+		return
+	}
+	pwd, err := PasswordFromBytes(data)
+	if err != nil {
+		m.receiver.ErrorData(PasswordChangeResponse, data, DataDomainError)
+		return
+	}
+	receiver.PasswordChangeResponse(true, pwd, 0)
+}
+
+func (m *MeterDataCodec) parseBaudChangeResponse(funcCode byte, data []byte) {
+	receiver, ok := m.receiver.(BaudChangeResponseReceiver)
+	if !ok {
+		m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		return
+	}
+	if funcCode == BaudChangeErrResponse {
+		receiver.BaudChangeResponse(false, 0, obtainErrCode(data))
+		return
+	}
+	if len(data) != 1 {
+		m.receiver.ErrorData(BaudChangeResponse, data, DataDomainError)
+		return
+	}
+	rate, err := BaudRateFromFeature(data[0])
+	if err != nil {
+		m.receiver.ErrorData(BaudChangeResponse, data, err)
+		return
+	}
+	receiver.BaudChangeResponse(true, rate, 0)
+}
+
+func (m *MeterDataCodec) parseSecurityResponse(funcCode byte, data []byte) {
+	receiver, ok := m.receiver.(SecurityResponseReceiver)
+	if !ok {
+		m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		return
+	}
+	if funcCode == SecurityErrResponse {
+		if len(data) != 2 {
+			m.receiver.ErrorData(SecurityErrResponse, data, DataDomainError)
+			return
+		}
+		receiver.SecurityResponse(false, nil, nil, uint16(data[0])|uint16(data[1])<<8)
+		return
+	}
+	if len(data) < 4 {
+		m.receiver.ErrorData(SecurityResponse, data, DataDomainError)
+		return
+	}
+	receiver.SecurityResponse(true, data[:4], data[4:], 0)
+}
+
+func (m *MeterDataCodec) parseMultiOutputResponse(funcCode byte, data []byte) {
+	receiver, ok := m.receiver.(MultiOutputResponseReceiver)
+	if !ok {
+		m.receiver.ErrorData(funcCode, data, FuncCodeError)
+		return
+	}
+	if funcCode == MultiOutputErrResponse {
+		receiver.MultiOutputResponse(false, 0, obtainErrCode(data))
+		return
+	}
+	if len(data) != 1 {
+		m.receiver.ErrorData(MultiOutputResponse, data, DataDomainError)
+		return
+	}
+	receiver.MultiOutputResponse(true, MultiFunctionOutput(data[0]), 0)
+}
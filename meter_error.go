@@ -0,0 +1,83 @@
+package go_dlt645_2007
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MeterError 从站异常应答的错误信息字，每一位表示一种错误，可以同时有多位为1
+type MeterError byte
+
+const (
+	MeterErrOther        MeterError = 0x01 //其他错误
+	MeterErrNoData       MeterError = 0x02 //无请求数据
+	MeterErrUnauthorized MeterError = 0x04 //密码错/未授权
+	MeterErrBaudRate     MeterError = 0x08 //通信速率不能更改
+	MeterErrYearZone     MeterError = 0x10 //年时区数超
+	MeterErrDailySlot    MeterError = 0x20 //日时段数超
+	MeterErrTariff       MeterError = 0x40 //费率数超
+)
+
+// 安全认证异常应答的错误信息字(SERR)，每一位表示一种错误，低字节在前发送
+const (
+	SecurityErrOther         uint16 = 0x0001 //其他错误
+	SecurityErrRecharge      uint16 = 0x0002 //重复充值
+	SecurityErrESAM          uint16 = 0x0004 //ESAM验证失败
+	SecurityErrIdentityAuth  uint16 = 0x0008 //身份认证失败
+	SecurityErrCustomer      uint16 = 0x0010 //客户编号不匹配
+	SecurityErrRechargeCount uint16 = 0x0020 //充值次数错误
+	SecurityErrHoarding      uint16 = 0x0040 //购电超囤积
+)
+
+var meterErrorNames = []struct {
+	bit  MeterError
+	name string
+}{
+	{MeterErrOther, "other error"},
+	{MeterErrNoData, "no requested data"},
+	{MeterErrUnauthorized, "password error or unauthorized"},
+	{MeterErrBaudRate, "baud rate cannot be changed"},
+	{MeterErrYearZone, "year zone count exceeded"},
+	{MeterErrDailySlot, "daily slot count exceeded"},
+	{MeterErrTariff, "tariff count exceeded"},
+}
+
+func (e MeterError) Error() string {
+	return "dlt645_2007: " + e.describe()
+}
+
+// describe 错误位的含义，多位为1时以逗号分隔
+func (e MeterError) describe() string {
+	var names []string
+	for _, item := range meterErrorNames {
+		if e.Has(item.bit) {
+			names = append(names, item.name)
+		}
+	}
+	if rest := e &^ 0x7F; rest != 0 || len(names) == 0 {
+		names = append(names, fmt.Sprintf("unknown error %02X", byte(e)))
+	}
+	return strings.Join(names, ", ")
+}
+
+// Has 是否包含给定的错误位
+// bit 错误位，可以是多位的组合
+func (e MeterError) Has(bit MeterError) bool {
+	return bit != 0 && e&bit == bit
+}
+
+// Is 支持 errors.Is，目标为 MeterError 时按位判断，
+// 密码错/未授权匹配 PasswordError，通信速率不能更改匹配 BaudRateError
+func (e MeterError) Is(target error) bool {
+	switch t := target.(type) {
+	case MeterError:
+		return e.Has(t)
+	}
+	switch target {
+	case PasswordError:
+		return e.Has(MeterErrUnauthorized)
+	case BaudRateError:
+		return e.Has(MeterErrBaudRate)
+	}
+	return false
+}
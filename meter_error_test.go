@@ -0,0 +1,33 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestMeterError(t *testing.T) {
+	err := MeterErrUnauthorized | MeterErrTariff
+	if err.Error() != "dlt645_2007: password error or unauthorized, tariff count exceeded" {
+		t.Fatalf("unexpected message %q", err.Error())
+	}
+	if !errors.Is(err, MeterErrTariff) || !errors.Is(err, PasswordError) || errors.Is(err, MeterErrNoData) {
+		t.Fatal("unexpected errors.Is result")
+	}
+	if MeterError(0).Error() != "dlt645_2007: unknown error 00" {
+		t.Fatalf("unexpected message %q", MeterError(0).Error())
+	}
+}
+
+func TestClientAbnormalMeterError(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	go NewSimulator("000000013310").Serve(slave)
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	_, err := client.Read(context.Background(), []byte{0x02, 0x01, 0x01, 0x00})
+	if !errors.Is(err, MeterErrNoData) {
+		t.Fatalf("expected no data error, got %v", err)
+	}
+}
@@ -45,9 +45,15 @@ func (m *MasterReadRequestModel) decode(data []byte) error {
 		m.hasBlock = true
 	}
 	if len(data) >= 10 {
+		m.block = data[4]
+		m.hasBlock = true
+		//mmhhDDMMYY
+		ts, err := DecodeTime(FormatYYMMDDhhmm, data[5:10], time.Local)
+		if err != nil {
+			return err
+		}
+		m.ts = ts
 		m.hasTs = true
-		mm, hh, DD, MM, YY := data[5], data[6], data[7], data[8], data[9]
-		m.ts = time.Date(2000+int(YY), time.Month(MM), int(DD), int(hh), int(mm), 0, 0, time.Local)
 	}
 	return nil
 }
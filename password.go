@@ -0,0 +1,47 @@
+package go_dlt645_2007
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	PasswordLevel02 byte = 0x02 //02级密码
+	PasswordLevel04 byte = 0x04 //04级密码
+)
+
+// PasswordError 密码错误或权限不足，从站以错误信息字 0x04 应答
+var PasswordError = errors.New("dlt645_2007: password error or unauthorized")
+
+// Password 密码，报文中为 PA P0 P1 P2
+type Password struct {
+	Level byte    //密码权限 PA，0~9，数字越小权限越高
+	Code  [3]byte //密码 P0 P1 P2，低字节在前
+}
+
+// PasswordFromBytes 从报文中的4字节密码 PA P0 P1 P2 创建密码
+// data 密码
+func PasswordFromBytes(data []byte) (Password, error) {
+	if len(data) != 4 {
+		return Password{}, errors.New("pwd length error")
+	}
+	return Password{Level: data[0], Code: [3]byte{data[1], data[2], data[3]}}, nil
+}
+
+// Bytes 报文中的4字节密码 PA P0 P1 P2
+func (p Password) Bytes() []byte {
+	return []byte{p.Level, p.Code[0], p.Code[1], p.Code[2]}
+}
+
+// String 密码权限及密码，密码高字节在前
+func (p Password) String() string {
+	return fmt.Sprintf("%02X:%02X%02X%02X", p.Level, p.Code[2], p.Code[1], p.Code[0])
+}
+
+// passwordIdent 修改密码时的数据标识，04000C01~04000C0A 对应 0~9 级密码
+func passwordIdent(level byte) ([]byte, error) {
+	if level > 9 {
+		return nil, fmt.Errorf("dlt645_2007: invalid password level %02X", level)
+	}
+	return []byte{0x04, 0x00, 0x0C, level + 1}, nil
+}
@@ -0,0 +1,30 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func TestBuildPasswordChangeRequest(t *testing.T) {
+	old := Password{Level: PasswordLevel02}
+	pwd := Password{Level: PasswordLevel04, Code: [3]byte{0x56, 0x34, 0x12}}
+	frame, err := BuildPasswordChangeRequest("", "000000013310", old, pwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &MeterDlt645Protocol{}
+	if err = resp.Decode(frame); err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{0x05, 0x0C, 0x00, 0x04, 0x02, 0x00, 0x00, 0x00, 0x04, 0x56, 0x34, 0x12}
+	if resp.ControlChar != PasswordChangeRequest || !bytes.Equal(resp.Data, expected) {
+		t.Fatalf("unexpected frame %s", hex.EncodeToString(frame))
+	}
+	if pwd.String() != "04:123456" {
+		t.Fatalf("unexpected password %s", pwd)
+	}
+	if _, err = BuildPasswordChangeRequest("", "000000013310", old, Password{Level: 0x10}); err == nil {
+		t.Fatal("expected invalid level error")
+	}
+}
@@ -0,0 +1,258 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff  = 5 * time.Second //表计无应答后默认的最短暂停时间
+	defaultMaxBackoff  = 5 * time.Minute //表计无应答后默认的最长暂停时间
+	defaultPollTimeout = time.Second     //默认的等待应答超时时间
+)
+
+// SampleQuality 采集数据的质量
+type SampleQuality int
+
+const (
+	QualityGood       SampleQuality = iota //正常
+	QualityAbnormal                        //表计异常应答，Err 为 *AbnormalResponseError
+	QualityInvalid                         //应答的数据标识、帧序号不匹配或数据域无法解析
+	QualityNoResponse                      //表计无应答或传输层错误
+)
+
+func (q SampleQuality) String() string {
+	switch q {
+	case QualityGood:
+		return "good"
+	case QualityAbnormal:
+		return "abnormal"
+	case QualityInvalid:
+		return "invalid"
+	case QualityNoResponse:
+		return "no response"
+	}
+	return "unknown"
+}
+
+// PollTask 抄读任务
+type PollTask struct {
+	Ident    []byte        //数据标识
+	Interval time.Duration //抄读周期
+}
+
+// PollMeter 被抄读的表计，Transport 相同的表计在同一条总线上，通过 Bus 依次抄读
+type PollMeter struct {
+	Address   string        //表计地址
+	Prefix    string        //唤醒符
+	Transport io.ReadWriter //传输层，例如串口或 TCPTransport
+	Tasks     []PollTask    //抄读任务
+}
+
+// Sample 采集数据
+type Sample struct {
+	Address string        //表计地址
+	Ident   []byte        //数据标识
+	Time    time.Time     //采集时间
+	Value   any           //解析结果，未注册解析器时为原始数据域 []byte
+	Quality SampleQuality //数据质量
+	Err     error         //质量不是 QualityGood 时的错误原因
+}
+
+// NewPoller 创建一个周期抄读引擎
+// sink 采集数据的接收通道，通道阻塞时抄读也会等待
+// timeout 等待应答的超时时间，不大于0时使用默认的1秒，抄读不能无限等待一块无应答的表计
+func NewPoller(sink chan<- Sample, timeout time.Duration) *Poller {
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+	return &Poller{sink: sink, timeout: timeout, codec: NewMeterDataCodec(nil), minBackoff: defaultMinBackoff, maxBackoff: defaultMaxBackoff}
+}
+
+// Poller 周期抄读引擎，按抄读任务的周期读取每块表计，读取结果使用注册的解析器解析后发送到 sink；
+// 表计无应答时暂停抄读这块表计，暂停时间从 minBackoff 开始每次加倍，最长 maxBackoff，收到应答后恢复
+type Poller struct {
+	sink       chan<- Sample
+	timeout    time.Duration
+	codec      *MeterDataCodec
+	codecMu    sync.Mutex
+	meters     []PollMeter
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// Register 注册数据解析器，必须在 Run 之前调用
+// ident 数据标识
+// parser 数据解析器
+func (p *Poller) Register(ident []byte, parser *MeterDataParser) {
+	p.codec.Register(ident, parser)
+}
+
+// RegisterDecoder 注册数据域解码器，必须在 Run 之前调用
+// ident 数据标识
+// decoder 数据域解码器
+func (p *Poller) RegisterDecoder(ident []byte, decoder DataDecoder) {
+	p.codec.RegisterDecoder(ident, decoder)
+}
+
+// RegisterCatalogue 启用数据标识目录，未注册解析器的数据标识按目录中的数据格式解析
+func (p *Poller) RegisterCatalogue() {
+	p.codec.RegisterCatalogue()
+}
+
+// SetBackoff 设置表计无应答后的暂停时间，必须在 Run 之前调用
+// min 第一次无应答后的暂停时间
+// max 最长暂停时间
+func (p *Poller) SetBackoff(min, max time.Duration) {
+	p.minBackoff, p.maxBackoff = min, max
+}
+
+// AddMeter 添加被抄读的表计，必须在 Run 之前调用
+// meter 表计及抄读任务
+func (p *Poller) AddMeter(meter PollMeter) error {
+	if strings.TrimSpace(meter.Address) == "" {
+		return errors.New("dlt645_2007 poller: address is empty")
+	}
+	if meter.Transport == nil {
+		return errors.New("dlt645_2007 poller: transport is nil")
+	}
+	if len(meter.Tasks) == 0 {
+		return errors.New("dlt645_2007 poller: no task")
+	}
+	for _, task := range meter.Tasks {
+		if len(task.Ident) != 4 {
+			return errors.New("dlt645_2007 poller: data ident length error")
+		}
+		if task.Interval <= 0 {
+			return errors.New("dlt645_2007 poller: interval must be positive")
+		}
+	}
+	p.meters = append(p.meters, meter)
+	return nil
+}
+
+// Run 开始抄读，直到 ctx 取消，返回 ctx.Err()
+func (p *Poller) Run(ctx context.Context) error {
+	buses := make(map[io.ReadWriter]*Bus)
+	var wg sync.WaitGroup
+	for _, meter := range p.meters {
+		bus, ok := buses[meter.Transport]
+		if !ok {
+			bus = NewBus(meter.Transport, p.timeout)
+			buses[meter.Transport] = bus
+		}
+		wg.Add(1)
+		go func(meter PollMeter) {
+			defer wg.Done()
+			p.poll(ctx, bus, meter)
+		}(meter)
+	}
+	<-ctx.Done()
+	for _, bus := range buses {
+		bus.Close()
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+// poll 按任务周期抄读一块表计
+func (p *Poller) poll(ctx context.Context, bus *Bus, meter PollMeter) {
+	m := NewMeter(meter.Prefix, meter.Address)
+	next := make([]time.Time, len(meter.Tasks)) //每个任务下一次抄读的时间
+	var resumeAt time.Time                      //暂停抄读的结束时间
+	var backoff time.Duration
+	for {
+		i := 0
+		for j := range next {
+			if next[j].Before(next[i]) {
+				i = j
+			}
+		}
+		due := next[i]
+		if resumeAt.After(due) {
+			due = resumeAt
+		}
+		if !sleepUntil(ctx, due) {
+			return
+		}
+		task := meter.Tasks[i]
+		sample := p.read(ctx, bus, m, task.Ident)
+		if ctx.Err() != nil {
+			return
+		}
+		next[i] = next[i].Add(task.Interval)
+		if now := time.Now(); next[i].Before(now) {
+			next[i] = now.Add(task.Interval)
+		}
+		if sample.Quality == QualityNoResponse {
+			backoff = min(max(backoff*2, p.minBackoff), p.maxBackoff)
+			resumeAt = time.Now().Add(backoff)
+		} else {
+			backoff, resumeAt = 0, time.Time{}
+		}
+		select {
+		case p.sink <- sample:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// read 读取一个数据标识并解析
+func (p *Poller) read(ctx context.Context, bus *Bus, meter *Meter, ident []byte) Sample {
+	sample := Sample{Address: meter.address, Ident: ident}
+	data, err := readAll(ctx, meter, func(ctx context.Context, frame []byte) (*MeterDlt645Protocol, error) {
+		return bus.Do(ctx, frame, PriorityPoll)
+	}, ident, 0, nil)
+	sample.Time = time.Now()
+	if err == nil {
+		p.codecMu.Lock()
+		sample.Value, err = p.codec.decode(ident, data)
+		p.codecMu.Unlock()
+	}
+	sample.Quality, sample.Err = sampleQuality(err), err
+	return sample
+}
+
+// sampleQuality 根据抄读的错误判断数据质量
+func sampleQuality(err error) SampleQuality {
+	var abnormal *AbnormalResponseError
+	var mismatch *ResponseMismatchError
+	switch {
+	case err == nil:
+		return QualityGood
+	case errors.As(err, &abnormal):
+		return QualityAbnormal
+	case errors.Is(err, ResponseTimeoutError), errors.Is(err, BusClosedError):
+		return QualityNoResponse
+	case errors.As(err, &mismatch), errors.Is(err, DataDomainError), errors.Is(err, SeqError):
+		return QualityInvalid
+	}
+	//传输层错误，例如 net.Error 和 *os.PathError
+	var transportErr interface{ Timeout() bool }
+	if errors.As(err, &transportErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return QualityNoResponse
+	}
+	//解析器返回的错误
+	return QualityInvalid
+}
+
+// sleepUntil 等待到给定时间，ctx 取消时返回 false
+func sleepUntil(ctx context.Context, t time.Time) bool {
+	delay := time.Until(t)
+	if delay <= 0 {
+		return ctx.Err() == nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,95 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPoller(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	defer slave.Close()
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	simulator := NewSimulator("000000013310")
+	simulator.SetValue(ident, []byte{0x19, 0x22})
+	go simulator.Serve(slave)
+
+	sink := make(chan Sample, 100)
+	//超时为0时使用默认超时，不会一直等待无应答的表计
+	if p := NewPoller(sink, 0); p.timeout != defaultPollTimeout {
+		t.Fatalf("unexpected timeout %v", p.timeout)
+	}
+	poller := NewPoller(sink, 30*time.Millisecond)
+	poller.SetBackoff(200*time.Millisecond, 400*time.Millisecond)
+	parser, _ := NewMeterDataParser(2, nil, 0.1, 0, "V")
+	poller.Register(ident, parser)
+	tasks := []PollTask{{Ident: ident, Interval: 50 * time.Millisecond}}
+	if err := poller.AddMeter(PollMeter{Address: "000000013310", Transport: master, Tasks: tasks}); err != nil {
+		t.Fatal(err)
+	}
+	//同一条总线上无应答的表计
+	if err := poller.AddMeter(PollMeter{Address: "000000013311", Transport: master, Tasks: tasks}); err != nil {
+		t.Fatal(err)
+	}
+	if err := poller.AddMeter(PollMeter{Address: "000000013312", Transport: master}); err == nil {
+		t.Fatal("expected error for meter without task")
+	}
+
+	//收到足够的样本后停止，不依赖固定的运行时间
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- poller.Run(ctx) }()
+	var good, dead []Sample
+	for len(good) < 5 || len(dead) < 3 {
+		var sample Sample
+		select {
+		case sample = <-sink:
+		case <-ctx.Done():
+			t.Fatalf("got %d good and %d dead samples before timeout", len(good), len(dead))
+		}
+		switch sample.Address {
+		case "000000013310":
+			if sample.Quality != QualityGood || sample.Value.(float64) < 221.89 || sample.Value.(float64) > 221.91 {
+				t.Fatalf("unexpected sample %+v", sample)
+			}
+			good = append(good, sample)
+		case "000000013311":
+			if sample.Quality != QualityNoResponse || sample.Err != ResponseTimeoutError {
+				t.Fatalf("unexpected sample %+v", sample)
+			}
+			dead = append(dead, sample)
+		}
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Fatalf("unexpected run error %v", err)
+	}
+	//无应答的表计暂停抄读：第一次暂停 200ms，之后加倍到最长 400ms，按采集时间计算实际的间隔
+	for i, backoff := range []time.Duration{200 * time.Millisecond, 400 * time.Millisecond} {
+		if d := dead[i+1].Time.Sub(dead[i].Time); d < backoff {
+			t.Fatalf("attempt %d: expected backoff of at least %v, got %v", i+1, backoff, d)
+		}
+	}
+}
+
+func TestSampleQuality(t *testing.T) {
+	cases := []struct {
+		err     error
+		quality SampleQuality
+	}{
+		{nil, QualityGood},
+		{&AbnormalResponseError{ControlChar: SlaveErrResponse, ErrCode: MeterErrNoData}, QualityAbnormal},
+		{ResponseTimeoutError, QualityNoResponse},
+		{&net.OpError{Op: "dial", Err: context.DeadlineExceeded}, QualityNoResponse},
+		{DataDomainError, QualityInvalid},
+		{SeqError, QualityInvalid},
+	}
+	for _, c := range cases {
+		if q := sampleQuality(c.err); q != c.quality {
+			t.Fatalf("%v: expected %v, got %v", c.err, c.quality, q)
+		}
+	}
+}
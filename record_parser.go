@@ -0,0 +1,188 @@
+package go_dlt645_2007
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DataDecoder 数据域解码器，MeterDataParser、TimeDataParser、RecordParser 都实现了这个接口
+type DataDecoder interface {
+	Decode(data []byte) (any, error)
+}
+
+// FieldDecoder 定长字段解码器，可以作为 RecordParser 的字段
+type FieldDecoder interface {
+	DataDecoder
+	Size() int //字段的字节数
+}
+
+// RecordField 记录中的一个字段
+type RecordField struct {
+	Name    string       //字段名称
+	Decoder FieldDecoder //字段解码器
+}
+
+// NewRecordParser 创建一个复合记录解析器，字段按报文中的先后顺序排列
+// fields 记录的字段
+func NewRecordParser(fields ...RecordField) (*RecordParser, error) {
+	if len(fields) == 0 {
+		return nil, errors.New("dlt645_2007 recordParser: no fields")
+	}
+	size := 0
+	for _, field := range fields {
+		if field.Name == "" || field.Decoder == nil || field.Decoder.Size() <= 0 {
+			return nil, fmt.Errorf("dlt645_2007 recordParser: invalid field %q", field.Name)
+		}
+		size += field.Decoder.Size()
+	}
+	return &RecordParser{fields: fields, size: size}, nil
+}
+
+// RecordParser 复合记录解析器，用于最大需量及发生时间、事件记录等由不同格式字段组成的数据项
+type RecordParser struct {
+	fields []RecordField
+	size   int
+	data   []map[string]any
+}
+
+// Size 单条记录的字节数
+func (p *RecordParser) Size() int {
+	return p.size
+}
+
+// ObtainValue 获取解析结果
+func (p *RecordParser) ObtainValue() (map[string]any, error) {
+	if len(p.data) == 0 {
+		return nil, errors.New("no data")
+	}
+	return p.data[0], nil
+}
+
+// ObtainValues 获取解析结果
+func (p *RecordParser) ObtainValues() []map[string]any {
+	return p.data
+}
+
+// Decode 解析数据域，单条记录返回 map[string]any，多条记录返回 []map[string]any
+func (p *RecordParser) Decode(data []byte) (any, error) {
+	p.data = nil
+	if len(data) == 0 || len(data)%p.size != 0 {
+		return nil, LengthMismatchError
+	}
+	for i := 0; i < len(data); i += p.size {
+		record := make(map[string]any, len(p.fields))
+		offset := i
+		for _, field := range p.fields {
+			value, err := field.Decoder.Decode(data[offset : offset+field.Decoder.Size()])
+			if err != nil {
+				return nil, fmt.Errorf("dlt645_2007 recordParser: field %s: %w", field.Name, err)
+			}
+			record[field.Name] = value
+			offset += field.Decoder.Size()
+		}
+		p.data = append(p.data, record)
+	}
+	if len(p.data) > 1 {
+		return p.ObtainValues(), nil
+	}
+	return p.ObtainValue()
+}
+
+// Unmarshal 解析数据域并写入结构体，v 为结构体指针或结构体切片的指针
+// 结构体字段通过 `dlt645:"字段名称"` 标签对应记录中的字段，没有标签时使用结构体字段名
+func (p *RecordParser) Unmarshal(data []byte, v any) error {
+	if _, err := p.Decode(data); err != nil {
+		return err
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return errors.New("dlt645_2007 recordParser: v must be a non-nil pointer")
+	}
+	rv = rv.Elem()
+	switch rv.Kind() {
+	case reflect.Struct:
+		return fillRecord(rv, p.data[0])
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Struct {
+			return errors.New("dlt645_2007 recordParser: v must point to a struct or a slice of structs")
+		}
+		slice := reflect.MakeSlice(rv.Type(), len(p.data), len(p.data))
+		for i, record := range p.data {
+			if err := fillRecord(slice.Index(i), record); err != nil {
+				return err
+			}
+		}
+		rv.Set(slice)
+		return nil
+	default:
+		return errors.New("dlt645_2007 recordParser: v must point to a struct or a slice of structs")
+	}
+}
+
+func fillRecord(rv reflect.Value, record map[string]any) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("dlt645"); ok {
+			if tag == "-" {
+				continue
+			}
+			name = tag
+		}
+		value, ok := record[name]
+		if !ok || value == nil {
+			continue
+		}
+		val := reflect.ValueOf(value)
+		switch {
+		case val.Type().AssignableTo(field.Type):
+			rv.Field(i).Set(val)
+		case val.Type().ConvertibleTo(field.Type):
+			rv.Field(i).Set(val.Convert(field.Type))
+		default:
+			return fmt.Errorf("dlt645_2007 recordParser: can not assign %T to field %s", value, field.Name)
+		}
+	}
+	return nil
+}
+
+// NewHexDataParser 创建一个十六进制字符串解析器，用于操作者代码、数据标识、表号以及状态字、特征字等按位定义的数据，结果高字节在前
+// size 数据长度
+func NewHexDataParser(size int) (*HexDataParser, error) {
+	if size <= 0 {
+		return nil, errors.New("dlt645_2007 hexDataParser: size must be positive")
+	}
+	return &HexDataParser{size: size}, nil
+}
+
+// HexDataParser 十六进制字符串解析器
+type HexDataParser struct {
+	size int
+}
+
+// Size 数据长度
+func (p *HexDataParser) Size() int {
+	return p.size
+}
+
+// Decode 解析数据域，单个数据项返回 string，多个数据项返回 []string
+func (p *HexDataParser) Decode(data []byte) (any, error) {
+	if len(data) == 0 || len(data)%p.size != 0 {
+		return nil, LengthMismatchError
+	}
+	var values []string
+	for i := 0; i < len(data); i += p.size {
+		values = append(values, strings.ToUpper(hex.EncodeToString(reverseBytes(data[i:i+p.size]))))
+	}
+	if len(values) > 1 {
+		return values, nil
+	}
+	return values[0], nil
+}
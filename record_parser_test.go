@@ -0,0 +1,90 @@
+package go_dlt645_2007
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRecordParser(t *testing.T) {
+	demand, err := NewMeterDataParser(3, nil, 0.0001, 0, "kW")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, err := NewTimeDataParser(FormatYYMMDDhhmm, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parser, err := NewRecordParser(RecordField{Name: "value", Decoder: demand}, RecordField{Name: "time", Decoder: at})
+	if err != nil {
+		t.Fatal(err)
+	}
+	//1.2345 kW，2024-03-15 13:45
+	data := []byte{0x45, 0x23, 0x01, 0x45, 0x13, 0x15, 0x03, 0x24}
+	var record struct {
+		Value float64   `dlt645:"value"`
+		Time  time.Time `dlt645:"time"`
+	}
+	if err = parser.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	if record.Value < 1.2344 || record.Value > 1.2346 || !record.Time.Equal(time.Date(2024, 3, 15, 13, 45, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected record %+v", record)
+	}
+	var records []struct {
+		Value float64 `dlt645:"value"`
+	}
+	if err = parser.Unmarshal(append(data, data...), &records); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("unexpected records %+v", records)
+	}
+}
+
+// valueRecorder 只实现 MeterDataReceiver 中读数据会用到的方法
+type valueRecorder struct {
+	MeterDataReceiver
+	fallback bool
+}
+
+func (r *valueRecorder) MeterDefaultReadResponse(funcCode byte, data []byte) {
+	r.fallback = true
+}
+
+// optionalValueRecorder 同时实现了 MeterValueReceiver
+type optionalValueRecorder struct {
+	valueRecorder
+	value any
+}
+
+func (r *optionalValueRecorder) MeterReadValue(ident []byte, value any, hasNext bool, seq byte) {
+	r.value = value
+}
+
+func TestMeterValueReceiver(t *testing.T) {
+	ident := []byte{0x04, 0x00, 0x01, 0x02}
+	frame, err := BuildMasterReadResponse[[]byte]("", "000000013310", ident, &MeterData[[]byte]{Value: []byte{0x30, 0x45, 0x13}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pro := &MeterDlt645Protocol{}
+	if err = pro.Decode(frame); err != nil {
+		t.Fatal(err)
+	}
+	at, _ := NewTimeDataParser(Formathhmmss, time.UTC)
+	//没有实现 MeterValueReceiver 时按没有注册解析器处理
+	recorder := &valueRecorder{}
+	codec := NewMeterDataCodec(recorder)
+	codec.RegisterDecoder(ident, at)
+	codec.ParseData(pro.ControlChar, pro.Data)
+	if !recorder.fallback {
+		t.Fatal("MeterDefaultReadResponse not called")
+	}
+	optional := &optionalValueRecorder{}
+	codec = NewMeterDataCodec(optional)
+	codec.RegisterDecoder(ident, at)
+	codec.ParseData(pro.ControlChar, pro.Data)
+	if optional.fallback || !optional.value.(time.Time).Equal(time.Date(0, 1, 1, 13, 45, 30, 0, time.UTC)) {
+		t.Fatalf("unexpected value %v", optional.value)
+	}
+}
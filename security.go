@@ -0,0 +1,222 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	PasswordLevel98 byte = 0x98 //密文+MAC，数据由安全模块加密并计算MAC
+	PasswordLevel99 byte = 0x99 //明文+MAC，数据由安全模块计算MAC
+)
+
+// IdentityAuthIdent 身份认证的数据标识，密钥更新等其他安全认证命令使用 BuildSecurityRequest 并传入对应的数据标识
+var IdentityAuthIdent = []byte{0x07, 0x00, 0x00, 0xFF}
+
+// SecurityError 安全认证异常应答，SERR 为安全认证错误信息字，各位的含义见 SecurityErr* 常量
+type SecurityError struct {
+	SERR uint16
+}
+
+func (e *SecurityError) Error() string {
+	return fmt.Sprintf("dlt645_2007: security authentication error %04X", e.SERR)
+}
+
+// SecurityModule 安全模块(ESAM)，提供随机数、加密和MAC计算
+type SecurityModule interface {
+	Random(n int) ([]byte, error)         //生成n字节随机数
+	Encrypt(plain []byte) ([]byte, error) //加密数据
+	MAC(data []byte) ([]byte, error)      //计算4字节MAC
+}
+
+// NewSoftwareSecurityModule 创建一个软件实现的安全模块，使用 AES 代替 ESAM 中的算法，仅用于测试
+// key 密钥，16、24或32字节
+func NewSoftwareSecurityModule(key []byte) (*SoftwareSecurityModule, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &SoftwareSecurityModule{block: block}, nil
+}
+
+// SoftwareSecurityModule 软件安全模块，加密使用 AES-CBC(零IV，80H填充)，MAC 取 CBC-MAC 的前4字节
+type SoftwareSecurityModule struct {
+	block cipher.Block
+}
+
+var _ SecurityModule = (*SoftwareSecurityModule)(nil)
+
+// Random 生成n字节随机数
+func (m *SoftwareSecurityModule) Random(n int) ([]byte, error) {
+	data := make([]byte, n)
+	if _, err := rand.Read(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Encrypt 加密数据
+func (m *SoftwareSecurityModule) Encrypt(plain []byte) ([]byte, error) {
+	data := m.pad(plain)
+	cipher.NewCBCEncrypter(m.block, make([]byte, m.block.BlockSize())).CryptBlocks(data, data)
+	return data, nil
+}
+
+// Decrypt 解密由 Encrypt 加密的数据
+func (m *SoftwareSecurityModule) Decrypt(data []byte) ([]byte, error) {
+	if len(data) == 0 || len(data)%m.block.BlockSize() != 0 {
+		return nil, LengthMismatchError
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(m.block, make([]byte, m.block.BlockSize())).CryptBlocks(plain, data)
+	end := bytes.LastIndexByte(plain, 0x80)
+	if end < 0 || len(plain)-end > m.block.BlockSize() || bytes.IndexFunc(plain[end+1:], func(r rune) bool { return r != 0 }) >= 0 {
+		return nil, errors.New("dlt645_2007: invalid padding")
+	}
+	return plain[:end], nil
+}
+
+// Open 校验98级或99级的安全数据并返回明文，是 securePayload 的逆过程
+// level 密码权限，98 或 99
+// header MAC 覆盖的报文头，例如写数据时的数据标识
+// payload 安全数据
+func (m *SoftwareSecurityModule) Open(level byte, header, payload []byte) ([]byte, error) {
+	if len(payload) < 4 {
+		return nil, LengthMismatchError
+	}
+	data, mac := payload[:len(payload)-4], payload[len(payload)-4:]
+	expected, _ := m.MAC(append(append([]byte(nil), header...), data...))
+	if !bytes.Equal(expected, mac) {
+		return nil, errors.New("dlt645_2007: mac mismatch")
+	}
+	switch level {
+	case PasswordLevel98:
+		return m.Decrypt(data)
+	case PasswordLevel99:
+		return data, nil
+	default:
+		return nil, fmt.Errorf("dlt645_2007: invalid security password level %02X", level)
+	}
+}
+
+// MAC 计算4字节MAC
+func (m *SoftwareSecurityModule) MAC(data []byte) ([]byte, error) {
+	padded := m.pad(data)
+	cipher.NewCBCEncrypter(m.block, make([]byte, m.block.BlockSize())).CryptBlocks(padded, padded)
+	return padded[len(padded)-m.block.BlockSize():][:4], nil
+}
+
+// pad 80H填充到分组长度的整数倍
+func (m *SoftwareSecurityModule) pad(data []byte) []byte {
+	size := m.block.BlockSize()
+	padded := make([]byte, (len(data)/size+1)*size)
+	copy(padded, data)
+	padded[len(data)] = 0x80
+	return padded
+}
+
+// isSecureLevel 是否是98级或99级密码权限
+func isSecureLevel(level byte) bool {
+	return level == PasswordLevel98 || level == PasswordLevel99
+}
+
+// securePayload 按密码权限生成安全数据，98级为密文+MAC，99级为明文+MAC，MAC 覆盖 header 和数据
+func securePayload(module SecurityModule, level byte, header, plain []byte) ([]byte, error) {
+	if module == nil {
+		return nil, errors.New("dlt645_2007: security module is nil")
+	}
+	payload := append([]byte(nil), plain...)
+	switch level {
+	case PasswordLevel98:
+		encrypted, err := module.Encrypt(plain)
+		if err != nil {
+			return nil, err
+		}
+		payload = encrypted
+	case PasswordLevel99:
+	default:
+		return nil, fmt.Errorf("dlt645_2007: invalid security password level %02X", level)
+	}
+	mac, err := module.MAC(append(append([]byte(nil), header...), payload...))
+	if err != nil {
+		return nil, err
+	}
+	return append(payload, mac...), nil
+}
+
+// IdentityAuth 身份认证，返回表计的随机数2和ESAM序列号
+// ctx 上下文，取消时中止等待应答
+// module 安全模块
+// operatorCode 操作者代码
+// factor 分散因子，8字节
+func (c *Client) IdentityAuth(ctx context.Context, module SecurityModule, operatorCode, factor []byte) (random2, serial []byte, err error) {
+	frame, _, err := BuildIdentityAuthRequest(c.meter.prefix, c.meter.address, operatorCode, module, factor)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := c.Security(ctx, frame)
+	if err != nil {
+		return nil, nil, err
+	}
+	//随机数2(4字节)、ESAM序列号(8字节)
+	if len(data) != 12 {
+		return nil, nil, DataDomainError
+	}
+	return data[:4], data[4:], nil
+}
+
+// Security 发送安全认证请求，返回应答中数据标识之后的数据，异常应答返回 *SecurityError
+// ctx 上下文，取消时中止等待应答
+// frame 由 BuildSecurityRequest 等函数创建的请求帧
+func (c *Client) Security(ctx context.Context, frame []byte) ([]byte, error) {
+	resp, err := c.Request(ctx, frame)
+	var abnormal *AbnormalResponseError
+	if errors.As(err, &abnormal) && resp != nil && len(resp.Data) == 2 {
+		return nil, &SecurityError{SERR: binary.LittleEndian.Uint16(resp.Data)}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) < 4 {
+		return nil, DataDomainError
+	}
+	return resp.Data[4:], nil
+}
+
+// SecureSet 以98级(密文+MAC)或99级(明文+MAC)写数据
+// ctx 上下文，取消时中止等待应答
+// ident 数据标识
+// level 密码权限，98 或 99
+// operatorCode 操作者代码
+// value 数据，低字节在前
+// module 安全模块
+func (c *Client) SecureSet(ctx context.Context, ident []byte, level byte, operatorCode, value []byte, module SecurityModule) error {
+	frame, err := BuildSecureSetRequest(c.meter.prefix, c.meter.address, ident, level, operatorCode, value, module)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
+
+// SecureRelayControl 以98级(密文+MAC)跳合闸、报警、保电
+// ctx 上下文，取消时中止等待应答
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+// module 安全模块
+func (c *Client) SecureRelayControl(ctx context.Context, operatorCode []byte, cmd RelayCommand, deadline time.Time, module SecurityModule) error {
+	frame, err := BuildSecureRelayControlRequest(c.meter.prefix, c.meter.address, operatorCode, cmd, deadline, module)
+	if err != nil {
+		return err
+	}
+	_, err = c.Request(ctx, frame)
+	return err
+}
@@ -0,0 +1,78 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSoftwareSecurityModule(t *testing.T) {
+	module, err := NewSoftwareSecurityModule(bytes.Repeat([]byte{0x11}, 16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain := []byte{0x01, 0x02, 0x03}
+	for _, level := range []byte{PasswordLevel98, PasswordLevel99} {
+		payload, err := securePayload(module, level, []byte{0x01, 0x01, 0x00, 0x04}, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opened, err := module.Open(level, []byte{0x01, 0x01, 0x00, 0x04}, payload)
+		if err != nil || !bytes.Equal(opened, plain) {
+			t.Fatalf("unexpected plain % X %v", opened, err)
+		}
+		//MAC 覆盖数据标识
+		if _, err = module.Open(level, []byte{0x02, 0x01, 0x00, 0x04}, payload); err == nil {
+			t.Fatal("expected mac mismatch")
+		}
+	}
+}
+
+func TestClientSecurity(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	key := bytes.Repeat([]byte{0x22}, 16)
+	meterModule, _ := NewSoftwareSecurityModule(key)
+	simulator := NewSimulator("000000013310")
+	simulator.SetSecurityModule(meterModule)
+	go simulator.Serve(slave)
+
+	module, _ := NewSoftwareSecurityModule(key)
+	operator := []byte{0, 0, 0, 0}
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	random2, serial, err := client.IdentityAuth(context.Background(), module, operator, make([]byte, 8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(random2) != 4 || !bytes.Equal(serial, []byte{0x10, 0x33, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}) {
+		t.Fatalf("unexpected random2 % X serial % X", random2, serial)
+	}
+
+	other, _ := NewSoftwareSecurityModule(bytes.Repeat([]byte{0x33}, 16))
+	var securityErr *SecurityError
+	if _, _, err = client.IdentityAuth(context.Background(), other, operator, make([]byte, 8)); !errors.As(err, &securityErr) || securityErr.SERR != SecurityErrIdentityAuth {
+		t.Fatalf("expected identity auth error, got %v", err)
+	}
+
+	ident := []byte{0x04, 0x00, 0x01, 0x03}
+	if err = client.SecureSet(context.Background(), ident, PasswordLevel98, operator, []byte{0x15}, module); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := simulator.Value(ident); !bytes.Equal(v, []byte{0x15}) {
+		t.Fatalf("unexpected value % X", v)
+	}
+	var abnormal *AbnormalResponseError
+	if err = client.SecureSet(context.Background(), ident, PasswordLevel99, operator, []byte{0x15}, other); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x04 {
+		t.Fatalf("expected mac error, got %v", err)
+	}
+
+	if err = client.SecureRelayControl(context.Background(), operator, RelayGuarantee, time.Now().Add(time.Hour), module); err != nil {
+		t.Fatal(err)
+	}
+	if simulator.Relay() != RelayGuarantee {
+		t.Fatalf("unexpected relay command %X", simulator.Relay())
+	}
+}
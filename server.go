@@ -0,0 +1,328 @@
+package go_dlt645_2007
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ServerHandler 从站请求处理器，errCode 为错误信息字，不为0时应答异常帧，例如 MeterErrNoData
+// 数据标识均为 DI3 DI2 DI1 DI0，返回的数据低字节在前，可以使用 EncodeTime 等函数编码
+type ServerHandler interface {
+	// Read 读数据，hasNext 为 true 时主站会继续读后续帧
+	Read(ident []byte, req *MasterReadRequestModel) (value []byte, hasNext bool, errCode MeterError)
+	// ReadNext 读后续数据，seq 为帧序号
+	ReadNext(ident []byte, seq byte) (value []byte, hasNext bool, errCode MeterError)
+	// Set 写数据
+	Set(ident []byte, pwd Password, operator, value []byte) (errCode MeterError)
+	// SetAddress 设置通信地址，返回 false 时不应答
+	SetAddress(addr string) bool
+	// BroadcastTime 广播校时，广播命令不应答
+	BroadcastTime(ti time.Time)
+	// Freeze 冻结命令，mm hh DD MM 为BCD码，99 表示通配
+	Freeze(mm, hh, DD, MM byte) (errCode MeterError)
+	// RelayControl 跳合闸、报警、保电，deadline 为命令有效截止时间
+	RelayControl(pwd Password, operator []byte, cmd RelayCommand, deadline time.Time) (errCode MeterError)
+	// DemandClear 最大需量清零
+	DemandClear(pwd Password, operator []byte) (errCode MeterError)
+	// MeterClear 电表清零
+	MeterClear(pwd Password, operator []byte) (errCode MeterError)
+	// EventClear 事件清零，ident 为 FFFFFFFF 时清全部事件，DI0 为 FF 时清该类事件
+	EventClear(ident []byte, pwd Password, operator []byte) (errCode MeterError)
+	// ChangePassword 修改密码，原密码已经校验通过，成功后新密码替换从站服务中通过 RegisterPassword 注册的同级密码
+	ChangePassword(old, new Password) (errCode MeterError)
+	// ChangeBaud 更改通信速率，应答以原速率发送，需要在应答发出后再切换传输层的速率
+	ChangeBaud(rate int) (errCode MeterError)
+	// Security 安全认证，data 为数据标识和操作者代码之后的数据，serr 为安全认证错误信息字，不为0时应答异常帧
+	// 98级和99级的写数据同样交给 Set 处理，pwd 的权限为 98 或 99，value 为安全数据
+	Security(ident, operator, data []byte) (value []byte, serr uint16)
+	// SecureRelayControl 98级或99级的跳合闸、报警、保电，payload 为安全数据，由处理器通过安全模块校验和解密
+	SecureRelayControl(pwd Password, operator, payload []byte) (errCode MeterError)
+	// MultiFunctionOutput 多功能端子输出控制
+	MultiFunctionOutput(output MultiFunctionOutput) (errCode MeterError)
+}
+
+// NewServer 创建一个从站服务
+// prefix 应答帧的唤醒前缀
+// address 表计地址
+// handler 请求处理器
+func NewServer(prefix, address string, handler ServerHandler) *Server {
+	address = strings.ToLower(strings.TrimSpace(address))
+	for len(address) < 12 {
+		address = "0" + address
+	}
+	s := &Server{prefix: strings.TrimSpace(prefix), address: address, handler: handler}
+	s.codec = NewMasterDataCodec((*serverReceiver)(s))
+	return s
+}
+
+// Server 从站服务，从传输层读取请求帧，按地址过滤后交给 ServerHandler 处理并自动应答
+// 接收本表地址、广播地址 999999999999 和含 AA 通配的地址，广播地址的请求不应答
+type Server struct {
+	prefix    string
+	address   string
+	handler   ServerHandler
+	codec     *MasterDataCodec
+	mu        sync.Mutex
+	transport io.Writer
+	broadcast bool  //当前请求是否是广播
+	writeErr  error //应答时的写错误
+}
+
+// Address 表计地址
+func (s *Server) Address() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.address
+}
+
+// RegisterPassword 注册密码，注册过密码后，带密码的请求先校验密码，密码错误时以 MeterErrUnauthorized 应答
+// pwd 密码
+func (s *Server) RegisterPassword(pwd Password) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codec.RegisterPassword(pwd)
+}
+
+// Serve 在传输层上提供服务，直到传输层读写出错，传输层读到 EOF 时返回 io.EOF
+// transport 传输层，例如串口、pty 或 net.Conn
+func (s *Server) Serve(transport io.ReadWriter) error {
+	//报文格式错误时扫描器自动重新同步
+	scanner := NewFrameScanner(transport)
+	for scanner.Scan() {
+		if err := s.handle(transport, scanner.Frame()); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// handle 处理一帧请求
+func (s *Server) handle(transport io.Writer, frame *MeterDlt645Protocol) error {
+	//方向位为1的是从站发出的帧，忽略
+	if frame.ControlChar&0x80 != 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	broadcast := frame.Address == BroadcastAddress
+	if !broadcast && !matchAddress(frame.Address, s.address) {
+		return nil
+	}
+	s.transport, s.broadcast, s.writeErr = transport, broadcast, nil
+	s.codec.ParseData(frame.ControlChar, frame.Data)
+	return s.writeErr
+}
+
+// reply 发送应答帧，广播请求不应答
+func (s *Server) reply(frame []byte, err error) {
+	if err != nil || s.broadcast || s.writeErr != nil {
+		return
+	}
+	_, s.writeErr = s.transport.Write(frame)
+}
+
+// serverReceiver 将 MasterDataCodec 的回调转换为 ServerHandler 调用
+type serverReceiver Server
+
+var _ MasterDataReceiver = (*serverReceiver)(nil)
+var _ RelayControlRequestReceiver = (*serverReceiver)(nil)
+var _ DemandClearRequestReceiver = (*serverReceiver)(nil)
+var _ MeterClearRequestReceiver = (*serverReceiver)(nil)
+var _ EventClearRequestReceiver = (*serverReceiver)(nil)
+var _ PasswordChangeRequestReceiver = (*serverReceiver)(nil)
+var _ BaudChangeRequestReceiver = (*serverReceiver)(nil)
+var _ SecurityRequestReceiver = (*serverReceiver)(nil)
+var _ SecureRelayControlRequestReceiver = (*serverReceiver)(nil)
+var _ MultiOutputRequestReceiver = (*serverReceiver)(nil)
+
+func (r *serverReceiver) MasterReadRequest(req *MasterReadRequestModel) {
+	s := (*Server)(r)
+	ident := reverseBytes(req.ObtainIdent())
+	value, hasNext, errCode := s.handler.Read(ident, req)
+	if errCode != 0 {
+		s.reply(BuildMeterAbnormalResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildMasterReadResponse[[]byte](s.prefix, s.address, ident, &MeterData[[]byte]{Value: value}, hasNext))
+}
+
+func (r *serverReceiver) MasterReadNextRequest(ident []byte, seq byte) {
+	s := (*Server)(r)
+	ident = reverseBytes(ident)
+	value, hasNext, errCode := s.handler.ReadNext(ident, seq)
+	if errCode != 0 {
+		s.reply(BuildMeterReadNextErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildMeterReadNextDataResponse[[]byte](s.prefix, s.address, ident, &MeterData[[]byte]{Value: value}, seq, hasNext))
+}
+
+func (r *serverReceiver) MasterSetRequest(ident []byte, pwd Password, operator []byte, data []byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.Set(reverseBytes(ident), pwd, operator, data); errCode != 0 {
+		s.reply(BuildMeterSetErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildMeterSetResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) MasterReadMeterAddrRequest() {
+	s := (*Server)(r)
+	s.reply(BuildMasterReadMeterAddrResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) MasterSetMeterAddrRequest(addr string) {
+	s := (*Server)(r)
+	if !s.handler.SetAddress(addr) {
+		return
+	}
+	s.address = addr
+	s.reply(BuildMeterSetMeterAddrResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) BroadcastTimeCalibration(ss, mm, hh, DD, MM, YY byte) {
+	s := (*Server)(r)
+	ti, err := DecodeTime(FormatYYMMDDhhmmss, []byte{ss, mm, hh, DD, MM, YY}, time.Local)
+	if err != nil {
+		return
+	}
+	s.handler.BroadcastTime(ti)
+}
+
+func (r *serverReceiver) FreezeCommand(mm, hh, DD, MM byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.Freeze(mm, hh, DD, MM); errCode != 0 {
+		s.reply(BuildFreezeCommandErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildFreezeCommandResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) RelayControlRequest(pwd Password, operator []byte, cmd RelayCommand, deadline time.Time) {
+	s := (*Server)(r)
+	if errCode := s.handler.RelayControl(pwd, operator, cmd, deadline); errCode != 0 {
+		s.reply(BuildRelayControlErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildRelayControlResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) DemandClearRequest(pwd Password, operator []byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.DemandClear(pwd, operator); errCode != 0 {
+		s.reply(BuildDemandClearErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildDemandClearResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) MeterClearRequest(pwd Password, operator []byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.MeterClear(pwd, operator); errCode != 0 {
+		s.reply(BuildMeterClearErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildMeterClearResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) EventClearRequest(ident []byte, pwd Password, operator []byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.EventClear(reverseBytes(ident), pwd, operator); errCode != 0 {
+		s.reply(BuildEventClearErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildEventClearResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) PasswordChangeRequest(ident []byte, old, new Password) {
+	s := (*Server)(r)
+	if errCode := s.handler.ChangePassword(old, new); errCode != 0 {
+		s.reply(BuildPasswordChangeErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	//没有注册过密码的从站不校验密码，修改密码不会开启密码校验
+	s.codec.replacePassword(new)
+	s.reply(BuildPasswordChangeResponse(s.prefix, s.address, new))
+}
+
+func (r *serverReceiver) BaudChangeRequest(rate int) {
+	s := (*Server)(r)
+	if errCode := s.handler.ChangeBaud(rate); errCode != 0 {
+		s.reply(BuildBaudChangeErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildBaudChangeResponse(s.prefix, s.address, rate))
+}
+
+func (r *serverReceiver) SecurityRequest(ident []byte, operator []byte, data []byte) {
+	s := (*Server)(r)
+	ident = reverseBytes(ident)
+	value, serr := s.handler.Security(ident, operator, data)
+	if serr != 0 {
+		s.reply(BuildSecurityErrResponse(s.prefix, s.address, serr))
+		return
+	}
+	s.reply(BuildSecurityResponse(s.prefix, s.address, ident, value))
+}
+
+func (r *serverReceiver) SecureRelayControlRequest(pwd Password, operator []byte, payload []byte) {
+	s := (*Server)(r)
+	if errCode := s.handler.SecureRelayControl(pwd, operator, payload); errCode != 0 {
+		s.reply(BuildRelayControlErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildRelayControlResponse(s.prefix, s.address))
+}
+
+func (r *serverReceiver) MultiOutputRequest(output MultiFunctionOutput) {
+	s := (*Server)(r)
+	if errCode := s.handler.MultiFunctionOutput(output); errCode != 0 {
+		s.reply(BuildMultiOutputErrResponse(s.prefix, s.address, errCode))
+		return
+	}
+	s.reply(BuildMultiOutputResponse(s.prefix, s.address, output))
+}
+
+func (r *serverReceiver) ErrorData(funcCode byte, data []byte, err error) {
+	s := (*Server)(r)
+	//密码错误按密码错/未授权应答，不支持的通信速率按通信速率不能更改应答，数据域错误按其他错误应答
+	errCode := MeterErrOther
+	switch {
+	case errors.Is(err, PasswordError):
+		errCode = MeterErrUnauthorized
+	case errors.Is(err, BaudRateError):
+		errCode = MeterErrBaudRate
+	}
+	switch funcCode {
+	case MainStationRequestFrame:
+		s.reply(BuildMeterAbnormalResponse(s.prefix, s.address, errCode))
+	case ReadNextFrame:
+		s.reply(BuildMeterReadNextErrResponse(s.prefix, s.address, errCode))
+	case MasterSetRequest:
+		s.reply(BuildMeterSetErrResponse(s.prefix, s.address, errCode))
+	case FreezeCommand:
+		s.reply(BuildFreezeCommandErrResponse(s.prefix, s.address, errCode))
+	case RelayControlRequest:
+		s.reply(BuildRelayControlErrResponse(s.prefix, s.address, errCode))
+	case DemandClearRequest:
+		s.reply(BuildDemandClearErrResponse(s.prefix, s.address, errCode))
+	case MeterClearRequest:
+		s.reply(BuildMeterClearErrResponse(s.prefix, s.address, errCode))
+	case EventClearRequest:
+		s.reply(BuildEventClearErrResponse(s.prefix, s.address, errCode))
+	case PasswordChangeRequest:
+		s.reply(BuildPasswordChangeErrResponse(s.prefix, s.address, errCode))
+	case BaudChangeRequest:
+		s.reply(BuildBaudChangeErrResponse(s.prefix, s.address, errCode))
+	case SecurityRequest:
+		s.reply(BuildSecurityErrResponse(s.prefix, s.address, SecurityErrOther))
+	case MultiOutputRequest:
+		s.reply(BuildMultiOutputErrResponse(s.prefix, s.address, errCode))
+	}
+}
@@ -0,0 +1,139 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+type testHandler struct {
+	values map[string][]byte
+}
+
+func (h *testHandler) Read(ident []byte, req *MasterReadRequestModel) ([]byte, bool, MeterError) {
+	value, ok := h.values[string(ident)]
+	if !ok {
+		return nil, false, 0x02
+	}
+	return value, false, 0
+}
+
+func (h *testHandler) ReadNext(ident []byte, seq byte) ([]byte, bool, MeterError) {
+	return nil, false, 0x02
+}
+
+func (h *testHandler) Set(ident []byte, pwd Password, operator, value []byte) MeterError {
+	h.values[string(ident)] = value
+	return 0
+}
+
+func (h *testHandler) SetAddress(addr string) bool { return true }
+
+func (h *testHandler) BroadcastTime(ti time.Time) {}
+
+func (h *testHandler) Freeze(mm, hh, DD, MM byte) MeterError { return 0 }
+
+func (h *testHandler) RelayControl(pwd Password, operator []byte, cmd RelayCommand, deadline time.Time) MeterError {
+	return 0
+}
+
+func (h *testHandler) DemandClear(pwd Password, operator []byte) MeterError { return 0 }
+
+func (h *testHandler) MeterClear(pwd Password, operator []byte) MeterError { return 0 }
+
+func (h *testHandler) EventClear(ident []byte, pwd Password, operator []byte) MeterError { return 0 }
+
+func (h *testHandler) ChangePassword(old, new Password) MeterError { return 0 }
+
+func (h *testHandler) ChangeBaud(rate int) MeterError { return 0 }
+
+func (h *testHandler) Security(ident, operator, data []byte) ([]byte, uint16) {
+	return nil, SecurityErrOther
+}
+
+func (h *testHandler) SecureRelayControl(pwd Password, operator, payload []byte) MeterError {
+	return 0x04
+}
+
+func (h *testHandler) MultiFunctionOutput(output MultiFunctionOutput) MeterError { return 0 }
+
+func TestServer(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	handler := &testHandler{values: map[string][]byte{string(ident): {0x19, 0x22}}}
+	server := NewServer("FEFEFEFE", "13310", handler)
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(slave) }()
+
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	resp, err := client.Read(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Data, []byte{0x00, 0x01, 0x01, 0x02, 0x19, 0x22}) {
+		t.Fatalf("unexpected data % X", resp.Data)
+	}
+	var abnormal *AbnormalResponseError
+	if _, err = client.Read(context.Background(), []byte{0x02, 0x01, 0x02, 0x00}); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x02 {
+		t.Fatalf("expected abnormal response, got %v", err)
+	}
+	if err = client.Set(context.Background(), ident, Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}, uint64(2300), 2); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(handler.values[string(ident)], []byte{0x00, 0x23}) {
+		t.Fatalf("unexpected value % X", handler.values[string(ident)])
+	}
+	address, err := NewClient(NewMeter("", ""), master, time.Second).ReadAddress(context.Background())
+	if err != nil || address != "000000013310" {
+		t.Fatalf("unexpected address %s, %v", address, err)
+	}
+	if err = client.Freeze(context.Background(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	//其他表计的请求不应答
+	other := NewClient(NewMeter("", "000000013311"), master, 50*time.Millisecond)
+	if _, err = other.Read(context.Background(), ident); !errors.Is(err, ResponseTimeoutError) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	master.Close()
+	if err = <-done; err == nil {
+		t.Fatal("expected transport error")
+	}
+}
+
+func TestServerPasswordChange(t *testing.T) {
+	master, slave := tcpPipe(t)
+	defer master.Close()
+	ident := []byte{0x04, 0x00, 0x01, 0x03}
+	handler := &testHandler{values: map[string][]byte{}}
+	server := NewServer("", "000000013310", handler)
+	go server.Serve(slave)
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	ctx := context.Background()
+	pwd04 := Password{Level: PasswordLevel04, Code: [3]byte{0x11, 0x11, 0x11}}
+
+	//没有注册过密码时修改密码不会开启密码校验
+	if _, err := client.ChangePassword(ctx, Password{Level: PasswordLevel04}, pwd04); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Set(ctx, ident, Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}, uint64(15), 1); err != nil {
+		t.Fatalf("set after password change: %v", err)
+	}
+
+	//注册过的同级密码被新密码替换
+	server.RegisterPassword(pwd04)
+	newPwd := Password{Level: PasswordLevel04, Code: [3]byte{0x22, 0x22, 0x22}}
+	if _, err := client.ChangePassword(ctx, pwd04, newPwd); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Set(ctx, ident, pwd04, []byte{0, 0, 0, 0}, uint64(15), 1); !errors.Is(err, MeterErrUnauthorized) {
+		t.Fatalf("old password: expected MeterErrUnauthorized, got %v", err)
+	}
+	if err := client.Set(ctx, ident, newPwd, []byte{0, 0, 0, 0}, uint64(15), 1); err != nil {
+		t.Fatalf("new password: %v", err)
+	}
+}
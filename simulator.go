@@ -0,0 +1,418 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+var (
+	dateIdent = []byte{0x04, 0x00, 0x01, 0x01} //日期及星期
+	timeIdent = []byte{0x04, 0x00, 0x01, 0x02} //时间
+)
+
+// simulatorFrameSize 单帧应答中数据的最大字节数，数据域 L≤200，扣除数据标识和帧序号
+const simulatorFrameSize = 195
+
+// NewSimulator 创建一个虚拟电表，默认02级密码为 000000，带密码的请求由从站服务校验密码
+// address 表计地址
+func NewSimulator(address string) *Simulator {
+	s := &Simulator{
+		store:     make(map[string][]byte),
+		frozen:    make(map[string][]byte),
+		frameSize: simulatorFrameSize,
+		baudRate:  DefaultBaudRate,
+	}
+	s.server = NewServer("", address, s)
+	s.server.RegisterPassword(Password{Level: PasswordLevel02})
+	return s
+}
+
+// Simulator 虚拟电表，用于集成测试
+// 保存数据标识到数据的映射，应答读写请求，超长的数据自动拆分为后续帧，支持广播校时和冻结，
+// 可以模拟应答延迟、丢帧和校验码错误
+type Simulator struct {
+	mu          sync.Mutex
+	server      *Server
+	store       map[string][]byte       //数据标识 -> 数据，数据低字节在前
+	frozen      map[string][]byte       //最近一次冻结的数据
+	freezeTime  [4]byte                 //最近一次冻结命令的时间 mm hh DD MM
+	clockOffset time.Duration           //表计时钟与系统时钟的差
+	frameSize   int                     //单帧应答中数据的最大字节数
+	pending     []byte                  //待发送的后续帧数据
+	pendingKey  string                  //待发送的后续帧数据标识
+	latency     time.Duration           //应答延迟
+	dropRate    float64                 //丢帧概率
+	corruptRate float64                 //校验码错误概率
+	relay       RelayCommand            //最近一次执行的跳合闸、报警、保电命令
+	baudRate    int                     //通信速率
+	output      MultiFunctionOutput     //多功能端子输出信号类别
+	security    *SoftwareSecurityModule //安全模块，为 nil 时不支持安全认证和98级、99级密码
+}
+
+// SetValue 设置数据
+// ident 数据标识
+// value 数据，低字节在前
+func (s *Simulator) SetValue(ident []byte, value []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store[hex.EncodeToString(ident)] = append([]byte(nil), value...)
+}
+
+// Value 获取数据
+// ident 数据标识
+func (s *Simulator) Value(ident []byte) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.store[hex.EncodeToString(ident)]
+	return value, ok
+}
+
+// FrozenValue 获取最近一次冻结的数据
+// ident 数据标识
+func (s *Simulator) FrozenValue(ident []byte) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	value, ok := s.frozen[hex.EncodeToString(ident)]
+	return value, ok
+}
+
+// FreezeTime 获取最近一次冻结命令的时间 mm hh DD MM，BCD码，99 表示通配，没有收到冻结命令时全为0
+func (s *Simulator) FreezeTime() [4]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.freezeTime
+}
+
+// SetPassword 设置密码，同一权限的密码会被替换
+// pwd 密码
+func (s *Simulator) SetPassword(pwd Password) {
+	s.server.RegisterPassword(pwd)
+}
+
+// SetFrameSize 设置单帧应答中数据的最大字节数，超过时拆分为后续帧
+// size 最大字节数，1~195
+func (s *Simulator) SetFrameSize(size int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if size > 0 && size <= simulatorFrameSize {
+		s.frameSize = size
+	}
+}
+
+// SetFaults 设置故障模拟
+// latency 应答延迟
+// dropRate 丢帧概率 0~1
+// corruptRate 校验码错误概率 0~1
+func (s *Simulator) SetFaults(latency time.Duration, dropRate, corruptRate float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency, s.dropRate, s.corruptRate = latency, dropRate, corruptRate
+}
+
+// Relay 最近一次执行的跳合闸、报警、保电命令，未执行过时为0
+func (s *Simulator) Relay() RelayCommand {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.relay
+}
+
+// SetSecurityModule 设置安全模块，用于身份认证以及校验98级、99级的安全数据
+// module 安全模块，密钥需要与主站一致
+func (s *Simulator) SetSecurityModule(module *SoftwareSecurityModule) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.security = module
+}
+
+// Output 多功能端子当前的输出信号类别，默认为时钟秒脉冲
+func (s *Simulator) Output() MultiFunctionOutput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.output
+}
+
+// BaudRate 通信速率，虚拟电表只记录通信速率，不切换传输层
+func (s *Simulator) BaudRate() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.baudRate
+}
+
+// Now 表计时钟
+func (s *Simulator) Now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return time.Now().Add(s.clockOffset)
+}
+
+// Address 表计地址
+func (s *Simulator) Address() string {
+	return s.server.Address()
+}
+
+// Serve 在传输层上提供服务，直到传输层读写出错，可以是 net.Pipe、TCP 连接或 pty
+// transport 传输层
+func (s *Simulator) Serve(transport io.ReadWriter) error {
+	return s.server.Serve(&faultTransport{ReadWriter: transport, simulator: s})
+}
+
+/*---------------ServerHandler------------------*/
+
+var _ ServerHandler = (*Simulator)(nil)
+
+func (s *Simulator) Read(ident []byte, req *MasterReadRequestModel) ([]byte, bool, MeterError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := hex.EncodeToString(ident)
+	value, ok := s.store[key]
+	if !ok {
+		switch {
+		case bytes.Equal(ident, dateIdent):
+			value, _ = EncodeTime(FormatYYMMDDWW, time.Now().Add(s.clockOffset))
+		case bytes.Equal(ident, timeIdent):
+			value, _ = EncodeTime(Formathhmmss, time.Now().Add(s.clockOffset))
+		default:
+			return nil, false, MeterErrNoData
+		}
+	}
+	s.pending, s.pendingKey = nil, ""
+	if len(value) <= s.frameSize {
+		return value, false, 0
+	}
+	s.pending, s.pendingKey = value[s.frameSize:], key
+	return value[:s.frameSize], true, 0
+}
+
+func (s *Simulator) ReadNext(ident []byte, seq byte) ([]byte, bool, MeterError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pendingKey != hex.EncodeToString(ident) || len(s.pending) == 0 {
+		return nil, false, MeterErrNoData
+	}
+	if len(s.pending) <= s.frameSize {
+		value := s.pending
+		s.pending, s.pendingKey = nil, ""
+		return value, false, 0
+	}
+	value := s.pending[:s.frameSize]
+	s.pending = s.pending[s.frameSize:]
+	return value, true, 0
+}
+
+func (s *Simulator) Set(ident []byte, pwd Password, operator, value []byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if isSecureLevel(pwd.Level) {
+		if s.security == nil {
+			return MeterErrUnauthorized
+		}
+		plain, err := s.security.Open(pwd.Level, reverseBytes(ident), value)
+		if err != nil {
+			return MeterErrUnauthorized
+		}
+		value = plain
+	}
+	switch {
+	case bytes.Equal(ident, dateIdent):
+		date, err := DecodeTime(FormatYYMMDDWW, value, time.Local)
+		if err != nil {
+			return MeterErrOther
+		}
+		now := time.Now().Add(s.clockOffset)
+		ti := time.Date(date.Year(), date.Month(), date.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.Local)
+		s.clockOffset = time.Until(ti)
+	case bytes.Equal(ident, timeIdent):
+		clock, err := DecodeTime(Formathhmmss, value, time.Local)
+		if err != nil {
+			return MeterErrOther
+		}
+		now := time.Now().Add(s.clockOffset)
+		ti := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, time.Local)
+		s.clockOffset = time.Until(ti)
+	default:
+		s.store[hex.EncodeToString(ident)] = append([]byte(nil), value...)
+	}
+	return 0
+}
+
+func (s *Simulator) SetAddress(addr string) bool {
+	return true
+}
+
+func (s *Simulator) BroadcastTime(ti time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clockOffset = time.Until(ti)
+}
+
+// Freeze 收到冻结命令时立即冻结当前数据，不等待命令中的冻结时间，冻结时间由 FreezeTime 获取
+func (s *Simulator) Freeze(mm, hh, DD, MM byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.freezeTime = [4]byte{mm, hh, DD, MM}
+	s.frozen = make(map[string][]byte, len(s.store))
+	for key, value := range s.store {
+		s.frozen[key] = append([]byte(nil), value...)
+	}
+	return 0
+}
+
+func (s *Simulator) RelayControl(pwd Password, operator []byte, cmd RelayCommand, deadline time.Time) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.relayControl(cmd, deadline)
+}
+
+func (s *Simulator) SecureRelayControl(pwd Password, operator, payload []byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.security == nil {
+		return MeterErrUnauthorized
+	}
+	plain, err := s.security.Open(pwd.Level, nil, payload)
+	if err != nil || len(plain) != 8 {
+		return MeterErrUnauthorized
+	}
+	deadline, err := DecodeTime(FormatYYMMDDhhmmss, plain[2:], time.Local)
+	if err != nil {
+		return MeterErrOther
+	}
+	return s.relayControl(RelayCommand(plain[0]), deadline)
+}
+
+// relayControl 执行跳合闸、报警、保电命令
+func (s *Simulator) relayControl(cmd RelayCommand, deadline time.Time) MeterError {
+	switch cmd {
+	case RelayTrip, RelayCloseAllow, RelayCloseDirect, RelayAlarm, RelayAlarmRelease, RelayGuarantee, RelayGuaranteeRelease:
+	default:
+		return MeterErrOther
+	}
+	//命令已过有效截止时间
+	if deadline.Before(time.Now().Add(s.clockOffset)) {
+		return MeterErrOther
+	}
+	s.relay = cmd
+	return 0
+}
+
+func (s *Simulator) DemandClear(pwd Password, operator []byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clear(0x01)
+	return 0
+}
+
+func (s *Simulator) MeterClear(pwd Password, operator []byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	//电能量、最大需量、事件记录、冻结数据、负荷记录
+	s.clear(0x00, 0x01, 0x03, 0x05, 0x06)
+	return 0
+}
+
+func (s *Simulator) EventClear(ident []byte, pwd Password, operator []byte) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if bytes.Equal(ident, []byte{0xFF, 0xFF, 0xFF, 0xFF}) {
+		s.clear(0x03)
+		return 0
+	}
+	for key := range s.store {
+		stored, _ := hex.DecodeString(key)
+		if matchEventIdent(ident, stored) {
+			delete(s.store, key)
+		}
+	}
+	return 0
+}
+
+// clear 删除 DI3 为给定值的数据
+func (s *Simulator) clear(families ...byte) {
+	for key := range s.store {
+		stored, _ := hex.DecodeString(key)
+		if len(stored) > 0 && bytes.IndexByte(families, stored[0]) >= 0 {
+			delete(s.store, key)
+		}
+	}
+}
+
+// matchEventIdent 事件清零的数据标识是否包含给定的数据标识，FF 表示通配
+func matchEventIdent(pattern, ident []byte) bool {
+	if len(pattern) != len(ident) {
+		return false
+	}
+	for i := range pattern {
+		if pattern[i] != 0xFF && pattern[i] != ident[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Simulator) ChangePassword(old, new Password) MeterError {
+	return 0
+}
+
+func (s *Simulator) Security(ident, operator, data []byte) ([]byte, uint16) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.security == nil || !bytes.Equal(ident, IdentityAuthIdent) {
+		return nil, SecurityErrOther
+	}
+	//密文1、随机数1(8字节)、分散因子(8字节)
+	if len(data) < 24 {
+		return nil, SecurityErrOther
+	}
+	random1 := data[len(data)-16 : len(data)-8]
+	plain, err := s.security.Decrypt(data[:len(data)-16])
+	if err != nil || !bytes.Equal(plain, random1) {
+		return nil, SecurityErrIdentityAuth
+	}
+	random2, _ := s.security.Random(4)
+	address, _ := hex.DecodeString(s.server.address)
+	//随机数2、ESAM序列号，序列号由表计地址补齐8字节，低字节在前
+	return append(random2, reverseBytes(append([]byte{0x00, 0x00}, address...))...), 0
+}
+
+func (s *Simulator) MultiFunctionOutput(output MultiFunctionOutput) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.output = output
+	return 0
+}
+
+func (s *Simulator) ChangeBaud(rate int) MeterError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.baudRate = rate
+	return 0
+}
+
+// faultTransport 按虚拟电表的设置在应答时模拟延迟、丢帧和校验码错误
+type faultTransport struct {
+	io.ReadWriter
+	simulator *Simulator
+}
+
+func (t *faultTransport) Write(p []byte) (int, error) {
+	t.simulator.mu.Lock()
+	latency, dropRate, corruptRate := t.simulator.latency, t.simulator.dropRate, t.simulator.corruptRate
+	t.simulator.mu.Unlock()
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+	if dropRate > 0 && rand.Float64() < dropRate {
+		return len(p), nil
+	}
+	if corruptRate > 0 && rand.Float64() < corruptRate && len(p) >= 2 {
+		corrupted := append([]byte(nil), p...)
+		corrupted[len(corrupted)-2] ^= 0xFF
+		_, err := t.ReadWriter.Write(corrupted)
+		return len(p), err
+	}
+	return t.ReadWriter.Write(p)
+}
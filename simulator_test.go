@@ -0,0 +1,159 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestSimulator(t *testing.T) {
+	master, slave := net.Pipe()
+	defer master.Close()
+	simulator := NewSimulator("000000013310")
+	simulator.SetFrameSize(4)
+	go simulator.Serve(slave)
+
+	ident := []byte{0x02, 0x01, 0xFF, 0x00}
+	simulator.SetValue(ident, []byte{0x00, 0x22, 0x10, 0x22, 0x20, 0x22})
+	client := NewClient(NewMeter("", "000000013310"), master, time.Second)
+	value, err := client.ReadAll(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(value.([]byte), []byte{0x00, 0x22, 0x10, 0x22, 0x20, 0x22}) {
+		t.Fatalf("unexpected value % X", value)
+	}
+
+	//密码错误
+	setIdent := []byte{0x04, 0x00, 0x01, 0x03}
+	var abnormal *AbnormalResponseError
+	wrong := Password{Level: PasswordLevel02, Code: [3]byte{0x11, 0x11, 0x11}}
+	err = client.Set(context.Background(), setIdent, wrong, []byte{0, 0, 0, 0}, uint64(15), 1)
+	if !errors.As(err, &abnormal) || abnormal.ErrCode != 0x04 {
+		t.Fatalf("expected password error, got %v", err)
+	}
+	if err = client.Set(context.Background(), setIdent, Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}, uint64(15), 1); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := simulator.Value(setIdent); !bytes.Equal(v, []byte{0x15}) {
+		t.Fatalf("unexpected value % X", v)
+	}
+
+	//广播校时
+	ti := time.Date(2030, 1, 2, 3, 4, 5, 0, time.Local)
+	if err = NewClient(NewMeter("", BroadcastAddress), master, time.Second).BroadcastTime(context.Background(), ti); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if d := simulator.Now().Sub(ti); d < 0 || d > time.Second {
+		t.Fatalf("unexpected clock %v", simulator.Now())
+	}
+
+	if err = client.Freeze(context.Background(), time.Date(2024, 12, 31, 23, 59, 0, 0, time.Local)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := simulator.FrozenValue(setIdent); !ok {
+		t.Fatal("expected frozen value")
+	}
+	if got := simulator.FreezeTime(); got != [4]byte{0x59, 0x23, 0x31, 0x12} {
+		t.Fatalf("unexpected freeze time % X", got)
+	}
+
+	simulator.SetFaults(0, 1, 0)
+	client = NewClient(NewMeter("", "000000013310"), master, 50*time.Millisecond)
+	if _, err = client.Read(context.Background(), ident); !errors.Is(err, ResponseTimeoutError) {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+}
+
+// startSimulator 启动一个虚拟电表，返回虚拟电表和连接到它的客户端
+func startSimulator(t *testing.T) (*Simulator, *Client) {
+	master, slave := net.Pipe()
+	t.Cleanup(func() { master.Close() })
+	simulator := NewSimulator("000000013310")
+	go simulator.Serve(slave)
+	return simulator, NewClient(NewMeter("", "000000013310"), master, time.Second)
+}
+
+func TestSimulatorRelayControl(t *testing.T) {
+	simulator, client := startSimulator(t)
+	ctx := context.Background()
+	pwd, operator := Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}
+	var abnormal *AbnormalResponseError
+	//命令已经过了有效截止时间
+	if err := client.RelayControl(ctx, pwd, operator, RelayTrip, simulator.Now().Add(-time.Hour)); !errors.As(err, &abnormal) {
+		t.Fatalf("expected expired command to fail, got %v", err)
+	}
+	if err := client.RelayControl(ctx, pwd, operator, RelayTrip, simulator.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if simulator.Relay() != RelayTrip {
+		t.Fatalf("unexpected relay command %X", simulator.Relay())
+	}
+}
+
+func TestSimulatorClear(t *testing.T) {
+	simulator, client := startSimulator(t)
+	ctx := context.Background()
+	pwd, operator := Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}
+	var abnormal *AbnormalResponseError
+	//事件清零
+	eventIdent := []byte{0x03, 0x30, 0x01, 0x01}
+	simulator.SetValue(eventIdent, []byte{0x01})
+	if err := client.EventClear(ctx, []byte{0x03, 0x30, 0x01, 0xFF}, pwd, operator); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := simulator.Value(eventIdent); ok {
+		t.Fatal("expected event record to be cleared")
+	}
+	//密码错误
+	wrong := Password{Level: PasswordLevel02, Code: [3]byte{0x11, 0x11, 0x11}}
+	if err := client.MeterClear(ctx, wrong, operator); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x04 {
+		t.Fatalf("expected password error, got %v", err)
+	}
+	if err := client.MeterClear(ctx, pwd, operator); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.DemandClear(ctx, pwd, operator); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatorChangePassword(t *testing.T) {
+	_, client := startSimulator(t)
+	ctx := context.Background()
+	pwd, operator := Password{Level: PasswordLevel02}, []byte{0, 0, 0, 0}
+	var abnormal *AbnormalResponseError
+	//修改密码，04级密码不能修改02级密码
+	newPwd := Password{Level: PasswordLevel02, Code: [3]byte{0x56, 0x34, 0x12}}
+	if _, err := client.ChangePassword(ctx, Password{Level: PasswordLevel04}, newPwd); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x04 {
+		t.Fatalf("expected password error, got %v", err)
+	}
+	if changed, err := client.ChangePassword(ctx, pwd, newPwd); err != nil || changed != newPwd {
+		t.Fatalf("unexpected change password result %v %v", changed, err)
+	}
+	//修改后原密码失效
+	if err := client.DemandClear(ctx, pwd, operator); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x04 {
+		t.Fatalf("expected old password to be rejected, got %v", err)
+	}
+	if err := client.DemandClear(ctx, newPwd, operator); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSimulatorMultiOutput(t *testing.T) {
+	simulator, client := startSimulator(t)
+	ctx := context.Background()
+	if err := client.SetMultiFunctionOutput(ctx, OutputSlotSwitch); err != nil || simulator.Output() != OutputSlotSwitch {
+		t.Fatalf("unexpected output %X %v", simulator.Output(), err)
+	}
+	//不支持的输出信号类别
+	frame, _ := client.meter.BuildMultiOutputRequest(0x03)
+	var abnormal *AbnormalResponseError
+	if _, err := client.Request(ctx, frame); !errors.As(err, &abnormal) || abnormal.ErrCode != 0x01 {
+		t.Fatalf("expected abnormal response, got %v", err)
+	}
+}
@@ -0,0 +1,338 @@
+package go_dlt645_2007
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultReconnectInterval = time.Second      //默认的最短重连间隔
+	drainTimeout             = time.Millisecond //清空残留数据时等待的时间
+	drainBufferSize          = 256              //清空残留数据时每次读取的字节数
+	defaultDialTimeout       = 5 * time.Second  //默认的连接超时时间
+)
+
+// drainer 发送前可以清空残留数据的传输层
+type drainer interface {
+	Drain() error
+}
+
+// NewTCPTransport 创建一个 TCP 透传传输层，串口服务器工作在 TCP 服务器模式，由本端主动连接
+// address 串口服务器的地址，例如 192.168.1.10:502
+// dialTimeout 连接超时时间，0 表示使用默认的5秒
+func NewTCPTransport(address string, dialTimeout time.Duration) *TCPTransport {
+	if dialTimeout <= 0 {
+		dialTimeout = defaultDialTimeout
+	}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	return &TCPTransport{connect: func(ctx context.Context) (net.Conn, error) {
+		return dialer.DialContext(ctx, "tcp", address)
+	}, reconnectInterval: defaultReconnectInterval}
+}
+
+// NewTCPListenerTransport 创建一个 TCP 透传传输层，串口服务器工作在 TCP 客户端模式，由串口服务器连接本端
+// 连接断开后等待串口服务器重新连接，listener 由调用者关闭
+// listener 本端监听的端口，实现了 SetDeadline 时(例如 *net.TCPListener)直接中止等待，否则在后台等待连接
+func NewTCPListenerTransport(listener net.Listener) *TCPTransport {
+	a := &acceptor{listener: listener}
+	return &TCPTransport{connect: a.accept, reconnectInterval: defaultReconnectInterval}
+}
+
+// deadlineListener 支持设置超时的监听端口，例如 *net.TCPListener
+type deadlineListener interface {
+	net.Listener
+	SetDeadline(t time.Time) error
+}
+
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// acceptor 等待串口服务器连接，ctx 取消时中止等待
+type acceptor struct {
+	listener net.Listener
+	mu       sync.Mutex
+	pending  chan acceptResult //listener 不支持 SetDeadline 时正在后台进行的 Accept，中止等待后由下一次连接取走结果
+}
+
+func (a *acceptor) accept(ctx context.Context) (net.Conn, error) {
+	if listener, ok := a.listener.(deadlineListener); ok {
+		//取消时把超时设置为过去的时间，中止正在进行的 Accept
+		aborted := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			defer close(aborted)
+			listener.SetDeadline(time.Unix(1, 0))
+		})
+		conn, err := listener.Accept()
+		if !stop() {
+			<-aborted
+			listener.SetDeadline(time.Time{})
+		}
+		return conn, err
+	}
+	a.mu.Lock()
+	pending := a.pending
+	if pending == nil {
+		pending = make(chan acceptResult, 1)
+		a.pending = pending
+		go func() {
+			conn, err := a.listener.Accept()
+			pending <- acceptResult{conn: conn, err: err}
+		}()
+	}
+	a.mu.Unlock()
+	select {
+	case result := <-pending:
+		a.mu.Lock()
+		a.pending = nil
+		a.mu.Unlock()
+		return result.conn, result.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// TCPTransport RS-485 转以太网串口服务器的 TCP 透传传输层，可以直接用于 NewClient：
+// 连接在第一次读写时建立，断开后在下一次读写时自动重连；
+// 读超时由 SetReadDeadline 设置，重连后依然有效，正在进行的连接到达读超时或 Close 时中止；
+// Client 每次发送请求前调用 Drain 清空连接中残留的数据，避免把上一次请求迟到的应答当成本次的应答
+type TCPTransport struct {
+	connect           func(ctx context.Context) (net.Conn, error)
+	reconnectInterval time.Duration
+	mu                sync.Mutex
+	conn              net.Conn
+	deadline          time.Time     //读超时
+	changed           chan struct{} //读超时改变或关闭时关闭，通知正在进行的连接
+	lastAttempt       time.Time     //上一次连接失败的时间
+	lastErr           error         //上一次连接失败的错误
+	closed            bool
+}
+
+// SetReconnectInterval 设置最短重连间隔，距离上一次连接失败不足该间隔时直接返回上一次的错误
+// interval 重连间隔，0 表示每次都重新连接
+func (t *TCPTransport) SetReconnectInterval(interval time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.reconnectInterval = interval
+}
+
+// obtainConn 获取当前连接，没有连接时建立连接；建立连接时不持有锁，到达读超时或关闭时中止
+func (t *TCPTransport) obtainConn() (net.Conn, error) {
+	t.mu.Lock()
+	if t.closed {
+		t.mu.Unlock()
+		return nil, net.ErrClosed
+	}
+	if t.conn != nil {
+		conn := t.conn
+		t.mu.Unlock()
+		return conn, nil
+	}
+	if t.lastErr != nil && time.Since(t.lastAttempt) < t.reconnectInterval {
+		err := t.lastErr
+		t.mu.Unlock()
+		return nil, err
+	}
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		t.watch(ctx, cancel)
+	}()
+	conn, err := t.connect(ctx)
+	aborted := ctx.Err() != nil
+	cancel()
+	<-watched
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		switch {
+		case t.closed:
+			return nil, net.ErrClosed
+		case aborted:
+			return nil, os.ErrDeadlineExceeded
+		}
+		t.lastAttempt, t.lastErr = time.Now(), err
+		return nil, err
+	}
+	t.lastErr = nil
+	if t.closed {
+		conn.Close()
+		return nil, net.ErrClosed
+	}
+	if t.conn != nil {
+		//其他读写已经建立了连接
+		conn.Close()
+		return t.conn, nil
+	}
+	if err = conn.SetReadDeadline(t.deadline); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	t.conn = conn
+	return conn, nil
+}
+
+// watch 在读超时到达或关闭时取消正在进行的连接，读超时改变后按新的读超时等待
+func (t *TCPTransport) watch(ctx context.Context, cancel context.CancelFunc) {
+	for {
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			cancel()
+			return
+		}
+		deadline := t.deadline
+		if t.changed == nil {
+			t.changed = make(chan struct{})
+		}
+		changed := t.changed
+		t.mu.Unlock()
+		var expired <-chan time.Time
+		var timer *time.Timer
+		if !deadline.IsZero() {
+			timer = time.NewTimer(time.Until(deadline))
+			expired = timer.C
+		}
+		select {
+		case <-expired:
+			cancel()
+			return
+		case <-changed:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// notify 通知正在进行的连接读超时已经改变或已经关闭，调用者必须持有 t.mu
+func (t *TCPTransport) notify() {
+	if t.changed != nil {
+		close(t.changed)
+		t.changed = nil
+	}
+}
+
+// drop 关闭出错的连接，下一次读写时重新连接
+func (t *TCPTransport) drop(conn net.Conn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.conn == conn {
+		t.conn = nil
+	}
+	conn.Close()
+}
+
+// Read 从连接中读取数据，读超时以外的错误会关闭连接
+func (t *TCPTransport) Read(p []byte) (int, error) {
+	conn, err := t.obtainConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := conn.Read(p)
+	if err != nil && !isTimeout(err) {
+		t.drop(conn)
+	}
+	return n, err
+}
+
+// Write 向连接写入数据，写入失败时重新连接并重发一次
+func (t *TCPTransport) Write(p []byte) (int, error) {
+	conn, err := t.obtainConn()
+	if err != nil {
+		return 0, err
+	}
+	n, err := conn.Write(p)
+	if err == nil {
+		return n, nil
+	}
+	t.drop(conn)
+	if n > 0 {
+		//已经发出了部分数据，重发会在总线上产生错误的报文
+		return n, err
+	}
+	if conn, err = t.obtainConn(); err != nil {
+		return 0, err
+	}
+	if n, err = conn.Write(p); err != nil {
+		t.drop(conn)
+	}
+	return n, err
+}
+
+// SetReadDeadline 设置读超时，重连后的连接使用同样的读超时，正在进行的连接在到达读超时时中止
+func (t *TCPTransport) SetReadDeadline(deadline time.Time) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.deadline = deadline
+	t.notify()
+	if t.conn == nil {
+		return nil
+	}
+	return t.conn.SetReadDeadline(deadline)
+}
+
+// Drain 没有连接时建立连接，然后丢弃连接中已经收到的数据，发现连接已经断开时关闭连接，下一次写入时重新连接；
+// 等待连接和丢弃数据都不会超过当前的读超时
+func (t *TCPTransport) Drain() error {
+	conn, err := t.obtainConn()
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	deadline := time.Now().Add(drainTimeout)
+	if !t.deadline.IsZero() && t.deadline.Before(deadline) {
+		deadline = t.deadline
+	}
+	err = conn.SetReadDeadline(deadline)
+	t.mu.Unlock()
+	if err != nil {
+		t.drop(conn)
+		return nil
+	}
+	buf := make([]byte, drainBufferSize)
+	for {
+		_, err := conn.Read(buf)
+		if err == nil {
+			continue
+		}
+		if !isTimeout(err) {
+			t.drop(conn)
+			return nil
+		}
+		//恢复读超时，期间 SetReadDeadline 设置的读超时不会被覆盖
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		return conn.SetReadDeadline(t.deadline)
+	}
+}
+
+// Close 关闭连接并中止正在进行的连接，关闭后不再重连
+func (t *TCPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	t.notify()
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	if errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,196 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// serveSimulator 在本地 TCP 端口上模拟工作在服务器模式的串口服务器，每个连接由 simulator 应答
+func serveSimulator(t *testing.T, simulator *Simulator) (net.Listener, chan net.Conn) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go simulator.Serve(conn)
+		}
+	}()
+	return listener, conns
+}
+
+func TestTCPTransportReconnect(t *testing.T) {
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	simulator := NewSimulator("000000013310")
+	simulator.SetValue(ident, []byte{0x19, 0x22})
+	listener, conns := serveSimulator(t, simulator)
+	defer listener.Close()
+
+	transport := NewTCPTransport(listener.Addr().String(), time.Second)
+	defer transport.Close()
+	client := NewClient(NewMeter("FEFEFEFE", "000000013310"), transport, time.Second)
+	for i := 0; i < 3; i++ {
+		resp, err := client.Read(context.Background(), ident)
+		if err != nil {
+			t.Fatalf("read %d: %v", i, err)
+		}
+		if !bytes.Equal(resp.Data[4:], []byte{0x19, 0x22}) {
+			t.Fatalf("unexpected data % X", resp.Data)
+		}
+		//串口服务器断开连接，下一次请求前重连
+		(<-conns).Close()
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestTCPTransportDrain(t *testing.T) {
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	simulator := NewSimulator("000000013310")
+	simulator.SetValue(ident, []byte{0x19, 0x22})
+	listener, conns := serveSimulator(t, simulator)
+	defer listener.Close()
+
+	transport := NewTCPTransport(listener.Addr().String(), time.Second)
+	defer transport.Close()
+	client := NewClient(NewMeter("", "000000013310"), transport, time.Second)
+	if _, err := client.Read(context.Background(), ident); err != nil {
+		t.Fatal(err)
+	}
+	//上一次请求迟到的应答
+	stale, _ := BuildMasterReadResponse("", "000000013310", ident, &MeterData[uint64]{Value: 9999, Length: 2}, false)
+	(<-conns).Write(stale)
+	time.Sleep(20 * time.Millisecond)
+	resp, err := client.Read(context.Background(), ident)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(resp.Data[4:], []byte{0x19, 0x22}) {
+		t.Fatalf("stale response was not drained: % X", resp.Data)
+	}
+}
+
+func TestTCPListenerTransport(t *testing.T) {
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	simulator := NewSimulator("000000013310")
+	simulator.SetValue(ident, []byte{0x19, 0x22})
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	//串口服务器工作在客户端模式，主动连接主站
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		simulator.Serve(conn)
+	}()
+
+	transport := NewTCPListenerTransport(listener)
+	defer transport.Close()
+	client := NewClient(NewMeter("", "000000013310"), transport, 200*time.Millisecond)
+	if _, err = client.Read(context.Background(), ident); err != nil {
+		t.Fatal(err)
+	}
+	//未知表计的请求超时后连接依然可用
+	other := NewClient(NewMeter("", "000000013311"), transport, 50*time.Millisecond)
+	if _, err = other.Read(context.Background(), ident); err != ResponseTimeoutError {
+		t.Fatalf("expected timeout, got %v", err)
+	}
+	if _, err = client.Read(context.Background(), ident); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// plainListener 隐藏 SetDeadline，模拟不支持超时的监听端口
+type plainListener struct {
+	net.Listener
+}
+
+func TestTCPListenerTransportCancel(t *testing.T) {
+	ident := []byte{0x02, 0x01, 0x01, 0x00}
+	for _, plain := range []bool{false, true} {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		transport := NewTCPListenerTransport(listener)
+		if plain {
+			transport = NewTCPListenerTransport(plainListener{listener})
+		}
+		defer transport.Close()
+		//没有串口服务器连接，客户端没有超时时间，取消 ctx 后请求返回
+		client := NewClient(NewMeter("", "000000013310"), transport, 0)
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		_, err = client.Read(ctx, ident)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("plain %v: expected deadline exceeded, got %v", plain, err)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Fatalf("plain %v: request returned after %v", plain, d)
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		time.AfterFunc(50*time.Millisecond, cancel)
+		if _, err = client.Read(ctx, ident); !errors.Is(err, context.Canceled) {
+			t.Fatalf("plain %v: expected canceled, got %v", plain, err)
+		}
+		//取消后串口服务器连接，下一次请求正常
+		simulator := NewSimulator("000000013310")
+		simulator.SetValue(ident, []byte{0x19, 0x22})
+		go func() {
+			conn, err := net.Dial("tcp", listener.Addr().String())
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			simulator.Serve(conn)
+		}()
+		ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+		_, err = client.Read(ctx, ident)
+		cancel()
+		if err != nil {
+			t.Fatalf("plain %v: %v", plain, err)
+		}
+	}
+}
+
+func TestTCPTransportCloseWhileConnecting(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	transport := NewTCPListenerTransport(listener)
+	done := make(chan error, 1)
+	go func() {
+		_, err := transport.Read(make([]byte, 1))
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	//正在等待连接时关闭
+	transport.Close()
+	select {
+	case err = <-done:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Fatalf("expected net.ErrClosed, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not interrupt the pending accept")
+	}
+}
@@ -0,0 +1,212 @@
+package go_dlt645_2007
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// 日期时间数据格式，YY-年 MM-月 DD-日 WW-星期 hh-时 mm-分 ss-秒，传输时低字节在前
+const (
+	FormatYYMMDDhhmmss = "YYMMDDhhmmss"
+	FormatYYMMDDhhmm   = "YYMMDDhhmm"
+	FormatYYMMDDWW     = "YYMMDDWW"
+	FormatYYMMDD       = "YYMMDD"
+	FormatMMDDhhmm     = "MMDDhhmm"
+	FormatDDhh         = "DDhh"
+	FormatMMDD         = "MMDD"
+	Formathhmmss       = "hhmmss"
+	Formathhmm         = "hhmm"
+)
+
+// BcdError BCD码错误
+var BcdError = errors.New("dlt645_2007: invalid bcd")
+
+// NewTimeDataParser 创建一个日期时间数据解析器
+// format 数据格式，例如 YYMMDDhhmm
+// loc 时区，nil 表示 time.Local
+func NewTimeDataParser(format string, loc *time.Location) (*TimeDataParser, error) {
+	fields, err := timeFields(format)
+	if err != nil {
+		return nil, err
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return &TimeDataParser{format: format, fields: fields, loc: loc}, nil
+}
+
+// TimeDataParser 日期时间数据解析器，缺少的年月日按 0年1月1日 处理，含日期的格式全0时解析为零值时间
+type TimeDataParser struct {
+	format string         //数据格式
+	fields []string       //数据格式拆分后的字段，高字节在前
+	loc    *time.Location //时区
+	data   []time.Time
+}
+
+// Size 单个数据项的字节数
+func (p *TimeDataParser) Size() int {
+	return len(p.fields)
+}
+
+// ObtainValue 获取解析结果
+func (p *TimeDataParser) ObtainValue() (time.Time, error) {
+	if len(p.data) == 0 {
+		return time.Time{}, errors.New("no data")
+	}
+	return p.data[0], nil
+}
+
+// ObtainValues 获取解析结果
+func (p *TimeDataParser) ObtainValues() []time.Time {
+	return p.data
+}
+
+// Decode 解析数据域，单个数据项返回 time.Time，多个数据项返回 []time.Time
+func (p *TimeDataParser) Decode(data []byte) (any, error) {
+	p.data = nil
+	if len(data) == 0 || len(data)%p.Size() != 0 {
+		return nil, LengthMismatchError
+	}
+	for i := 0; i < len(data); i += p.Size() {
+		ti, err := decodeTime(p.fields, data[i:i+p.Size()], p.loc)
+		if err != nil {
+			return nil, err
+		}
+		p.data = append(p.data, ti)
+	}
+	if len(p.data) > 1 {
+		return p.ObtainValues(), nil
+	}
+	return p.ObtainValue()
+}
+
+// DecodeTime 按数据格式解析日期时间
+// format 数据格式
+// data 数据，低字节在前
+// loc 时区，nil 表示 time.Local
+func DecodeTime(format string, data []byte, loc *time.Location) (time.Time, error) {
+	fields, err := timeFields(format)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(data) != len(fields) {
+		return time.Time{}, LengthMismatchError
+	}
+	if loc == nil {
+		loc = time.Local
+	}
+	return decodeTime(fields, data, loc)
+}
+
+// EncodeTime 按数据格式编码日期时间，结果低字节在前，可以直接作为 BuildMasterSetRequest 的 []byte 设定值
+// format 数据格式
+// ti 时间
+func EncodeTime(format string, ti time.Time) ([]byte, error) {
+	fields, err := timeFields(format)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, len(fields))
+	for i, field := range fields {
+		var v int
+		switch field {
+		case "YY":
+			v = ti.Year() % 100
+		case "MM":
+			v = int(ti.Month())
+		case "DD":
+			v = ti.Day()
+		case "WW":
+			v = int(ti.Weekday())
+		case "hh":
+			v = ti.Hour()
+		case "mm":
+			v = ti.Minute()
+		case "ss":
+			v = ti.Second()
+		}
+		data[len(fields)-1-i] = toBcd(v)
+	}
+	return data, nil
+}
+
+func timeFields(format string) ([]string, error) {
+	if len(format) == 0 || len(format)%2 != 0 {
+		return nil, fmt.Errorf("dlt645_2007: invalid time format %s", format)
+	}
+	var fields []string
+	for i := 0; i < len(format); i += 2 {
+		field := format[i : i+2]
+		switch field {
+		case "YY", "MM", "DD", "WW", "hh", "mm", "ss":
+			fields = append(fields, field)
+		default:
+			return nil, fmt.Errorf("dlt645_2007: invalid time format %s", format)
+		}
+	}
+	return fields, nil
+}
+
+func decodeTime(fields []string, data []byte, loc *time.Location) (time.Time, error) {
+	//含日期的格式全0表示未设置，只有时间的格式全0是 00:00:00
+	if hasDate(fields) && isZero(data) {
+		return time.Time{}, nil
+	}
+	year, month, day, hour, minute, second := 0, 1, 1, 0, 0, 0
+	for i, field := range fields {
+		v, err := fromBcd(data[len(fields)-1-i])
+		if err != nil {
+			return time.Time{}, err
+		}
+		switch field {
+		case "YY":
+			year = 2000 + v
+		case "MM":
+			month = v
+		case "DD":
+			day = v
+		case "hh":
+			hour = v
+		case "mm":
+			minute = v
+		case "ss":
+			second = v
+		}
+	}
+	ti := time.Date(year, time.Month(month), day, hour, minute, second, 0, loc)
+	if ti.Month() != time.Month(month) || ti.Day() != day || ti.Hour() != hour || ti.Minute() != minute || ti.Second() != second {
+		return time.Time{}, fmt.Errorf("dlt645_2007: invalid time % X", data)
+	}
+	return ti, nil
+}
+
+// hasDate 数据格式是否包含年、月、日
+func hasDate(fields []string) bool {
+	for _, field := range fields {
+		if field == "YY" || field == "MM" || field == "DD" {
+			return true
+		}
+	}
+	return false
+}
+
+func isZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0x00 {
+			return false
+		}
+	}
+	return true
+}
+
+func toBcd(v int) byte {
+	return byte(v/10%10<<4 | v%10)
+}
+
+func fromBcd(b byte) (int, error) {
+	if b>>4 > 9 || b&0x0F > 9 {
+		return 0, BcdError
+	}
+	return int(b>>4)*10 + int(b&0x0F), nil
+}
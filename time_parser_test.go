@@ -0,0 +1,69 @@
+package go_dlt645_2007
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeDataParser(t *testing.T) {
+	parser, err := NewTimeDataParser(FormatYYMMDDWW, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//2024-03-15 星期五
+	value, err := parser.Decode([]byte{0x05, 0x15, 0x03, 0x24})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC); !value.(time.Time).Equal(want) {
+		t.Fatalf("got %v, want %v", value, want)
+	}
+	if _, err = parser.Decode([]byte{0x05, 0x32, 0x02, 0x24}); err == nil {
+		t.Fatal("expected invalid date error")
+	}
+}
+
+func TestEncodeTime(t *testing.T) {
+	ti := time.Date(2024, 3, 15, 13, 45, 30, 0, time.UTC)
+	cases := map[string][]byte{
+		FormatYYMMDDhhmmss: {0x30, 0x45, 0x13, 0x15, 0x03, 0x24},
+		FormatYYMMDDWW:     {0x05, 0x15, 0x03, 0x24},
+		Formathhmmss:       {0x30, 0x45, 0x13},
+	}
+	for format, want := range cases {
+		got, err := EncodeTime(format, ti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Fatalf("%s: got % X, want % X", format, got, want)
+		}
+		back, err := DecodeTime(format, got, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if format == FormatYYMMDDhhmmss && !back.Equal(ti) {
+			t.Fatalf("%s: got %v, want %v", format, back, ti)
+		}
+	}
+}
+
+func TestDecodeTimeMidnight(t *testing.T) {
+	for _, format := range []string{Formathhmmss, Formathhmm} {
+		midnight, err := DecodeTime(format, make([]byte, len(format)/2), time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		oneOClock, err := DecodeTime(format, append(make([]byte, len(format)/2-1), 0x01), time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if oneOClock.Sub(midnight) != time.Hour {
+			t.Fatalf("%s: midnight %v, 01:00 %v", format, midnight, oneOClock)
+		}
+	}
+	//含日期的格式全0表示未设置
+	if ti, err := DecodeTime(FormatYYMMDDhhmm, make([]byte, 5), time.UTC); err != nil || !ti.IsZero() {
+		t.Fatalf("got %v, %v", ti, err)
+	}
+}
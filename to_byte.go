@@ -4,6 +4,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"math"
 )
 
 // toLittleEndianBytes 将 MeterData 类型转换为小端字节序的 []byte
@@ -76,7 +77,10 @@ func uint64ToBytes(value uint64, length byte) ([]byte, error) {
 		return nil, errors.New("length must be greater than 0")
 	}
 	per := fmt.Sprintf("%d", value)
-	for len(per) < int(length*2) {
+	if len(per) > int(length)*2 {
+		return nil, fmt.Errorf("value %d overflows %d bytes", value, length)
+	}
+	for len(per) < int(length)*2 {
 		per = "0" + per
 	}
 	result, err := hex.DecodeString(per)
@@ -86,6 +90,22 @@ func uint64ToBytes(value uint64, length byte) ([]byte, error) {
 	return reverseBytes(result), nil
 }
 
+// int64ToBytes 负数编码为带符号BCD，最高字节的最高位为符号位
 func int64ToBytes(value int64, length byte) ([]byte, error) {
-	return uint64ToBytes(uint64(value), length)
+	if value >= 0 {
+		return uint64ToBytes(uint64(value), length)
+	}
+	magnitude := uint64(-value)
+	if value == math.MinInt64 {
+		magnitude = uint64(math.MaxInt64) + 1
+	}
+	result, err := uint64ToBytes(magnitude, length)
+	if err != nil {
+		return nil, err
+	}
+	if result[len(result)-1]&0x80 != 0 {
+		return nil, fmt.Errorf("value %d overflows %d bytes signed bcd", value, length)
+	}
+	result[len(result)-1] |= 0x80
+	return result, nil
 }
@@ -32,12 +32,12 @@ func BuildMasterReadRequest(prefix, meterId string, ident []byte, block byte, ts
 		data = append(data, block)
 	}
 	if ts != nil {
-		mm := ts.Minute()     // 分钟 (0-59)
-		hh := ts.Hour()       // 小时 (0-23)
-		DD := ts.Day()        // 日 (1-31)
-		MM := ts.Month()      // 月 (January-December)
-		YY := ts.Year() % 100 // 年 (四位数)
-		data = append(data, byte(mm), byte(hh), byte(DD), byte(MM), byte(YY))
+		//mmhhDDMMYY
+		tsArr, err := EncodeTime(FormatYYMMDDhhmm, *ts)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, tsArr...)
 	}
 	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: data, ControlChar: MainStationRequestFrame}
 	return statute.Encode()
@@ -54,7 +54,7 @@ func BuildMasterReadResponse[T ScalarOrVector](prefix, meterId string, ident []b
 		return nil, errors.New("data ident length error")
 	}
 	if value == nil {
-		statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: ident, ControlChar: RespondingNormallyNoNext}
+		statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: reverseBytes(ident), ControlChar: RespondingNormallyNoNext}
 		return statute.Encode()
 	}
 	controlCode := RespondingNormallyNoNext
@@ -75,8 +75,8 @@ func BuildMasterReadResponse[T ScalarOrVector](prefix, meterId string, ident []b
 // prefix 通配唤醒前缀
 // meterId 表地址
 // errCode 错误码
-func BuildMeterAbnormalResponse(prefix, meterId string, errCode byte) ([]byte, error) {
-	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{errCode}, ControlChar: SlaveErrResponse}
+func BuildMeterAbnormalResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{byte(errCode)}, ControlChar: SlaveErrResponse}
 	return statute.Encode()
 }
 
@@ -106,7 +106,7 @@ func BuildMeterReadNextDataResponse[T ScalarOrVector](prefix, meterId string, id
 		return nil, errors.New("data ident length error")
 	}
 	if value == nil {
-		statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: ident, ControlChar: NextRespondingNormallyNoNext}
+		statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: append(reverseBytes(ident), seq), ControlChar: NextRespondingNormallyNoNext}
 		return statute.Encode()
 	}
 	conctrlCode := NextRespondingNormallyNoNext
@@ -128,37 +128,34 @@ func BuildMeterReadNextDataResponse[T ScalarOrVector](prefix, meterId string, id
 // prefix 通配唤醒前缀
 // meterId 表地址
 // errCode 错误码
-func BuildMeterReadNextErrResponse(prefix, meterId string, errCode byte) ([]byte, error) {
-	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{errCode}, ControlChar: NextSlaveErrResponse}
+func BuildMeterReadNextErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{byte(errCode)}, ControlChar: NextSlaveErrResponse}
 	return statute.Encode()
 }
 
 /*---------------写数据------------------*/
 
 // BuildMasterSetRequest 构建一个主站向从站请求设置数据(或编程)的报文
+// 数据域依次为 DI0~DI3、密码、操作者代码、数据，数据之前没有长度字节，与早期版本的报文不兼容
 // prefix 通配唤醒前缀
 // meterId 表地址
-// ident 数据标识
+// ident 数据标识，DI3 在前
 // pwd 密码
 // operatorCode 操作者代码
 // Value 设定值
-func BuildMasterSetRequest[T ScalarOrVector](prefix, meterId string, ident, pwd, operatorCode []byte, value *MeterData[T]) ([]byte, error) {
+func BuildMasterSetRequest[T ScalarOrVector](prefix, meterId string, ident []byte, pwd Password, operatorCode []byte, value *MeterData[T]) ([]byte, error) {
 	if ident == nil || len(ident) != 4 {
 		return nil, errors.New("data ident length error")
 	}
-	if pwd == nil || len(pwd) != 4 {
-		return nil, errors.New("pwd length error")
-	}
 	if operatorCode == nil || len(operatorCode) != 4 {
 		return nil, errors.New("operatorCode length error")
 	}
-	data := append(ident, pwd...)
+	data := append(reverseBytes(ident), pwd.Bytes()...)
 	data = append(data, operatorCode...)
 	valArr, err := toLittleEndianBytes(value)
 	if err != nil {
 		return nil, err
 	}
-	data = append(data, byte(len(valArr)))
 	data = append(data, valArr...)
 	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: data, ControlChar: MasterSetRequest}
 	return statute.Encode()
@@ -176,8 +173,8 @@ func BuildMeterSetResponse(prefix, meterId string) ([]byte, error) {
 // prefix 通配唤醒前缀
 // meterId 表地址
 // errorCode 错误码
-func BuildMeterSetErrResponse(prefix, meterId string, errorCode byte) ([]byte, error) {
-	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{errorCode}, ControlChar: MeterSetErrResponse}
+func BuildMeterSetErrResponse(prefix, meterId string, errorCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, Data: []byte{byte(errorCode)}, ControlChar: MeterSetErrResponse}
 	return statute.Encode()
 }
 
@@ -228,31 +225,29 @@ func BuildMeterSetMeterAddrResponse(prefix string, address string) ([]byte, erro
 // prefix 通配唤醒前缀
 // ti 需要设置的时间
 func BuildBroadcastTimeCalibration(prefix string, ti time.Time) ([]byte, error) {
-	ss := ti.Second()     //秒
-	mm := ti.Minute()     // 分钟 (0-59)
-	hh := ti.Hour()       // 小时 (0-23)
-	DD := ti.Day()        // 日 (1-31)
-	MM := ti.Month()      // 月 (January-December)
-	YY := ti.Year() % 100 // 年 (四位数)
-	data := []byte{byte(ss), byte(mm), byte(hh), byte(DD), byte(MM), byte(YY)}
+	//ssmmhhDDMMYY
+	data, err := EncodeTime(FormatYYMMDDhhmmss, ti)
+	if err != nil {
+		return nil, err
+	}
 	statute := &MeterDlt645Protocol{prefix: prefix, Address: BroadcastAddress, ControlChar: BroadcastTimeCalibration, Data: data}
 	return statute.Encode()
 }
 
 // BuildFreezeCommandRequest 冻结命令
 // prefix 通配唤醒前缀
-// Address 电表地址
-// ti 冻结时间
+// Address 电表地址，为空时使用广播地址 999999999999
+// ti 冻结时间，按 BCD 码 mmhhDDMM 发送，与早期版本的二进制报文不兼容
 func BuildFreezeCommandRequest(prefix, address string, ti time.Time) ([]byte, error) {
 	if strings.TrimSpace(address) == "" {
 		address = BroadcastAddress
 	}
-	mm := ti.Minute() // 分钟 (0-59)
-	hh := ti.Hour()   // 小时 (0-23)
-	DD := ti.Day()    // 日 (1-31)
-	MM := ti.Month()  // 月 (January-December)
-	data := []byte{byte(mm), byte(hh), byte(DD), byte(MM)}
-	statute := &MeterDlt645Protocol{prefix: prefix, Address: BroadcastAddress, ControlChar: FreezeCommand, Data: data}
+	//mmhhDDMM
+	data, err := EncodeTime(FormatMMDDhhmm, ti)
+	if err != nil {
+		return nil, err
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: address, ControlChar: FreezeCommand, Data: data}
 	return statute.Encode()
 }
 
@@ -260,18 +255,369 @@ func BuildFreezeCommandRequest(prefix, address string, ti time.Time) ([]byte, er
 // prefix 通配唤醒前缀
 // Address 电表地址
 func BuildFreezeCommandResponse(prefix, address string) ([]byte, error) {
-	statute := &MeterDlt645Protocol{prefix: prefix, Address: BroadcastAddress, ControlChar: FreezeCommandResponse}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: address, ControlChar: FreezeCommandResponse}
 	return statute.Encode()
 }
 
-// BuildFreezeCommandErrorResponse 生成一个冻结命令的异常回复报文
+// BuildFreezeCommandErrorResponse 生成一个冻结命令的异常回复报文，不带错误信息字
 // prefix 通配唤醒前缀
 // Address 电表地址
+//
+// Deprecated: 异常应答需要带错误信息字，使用 BuildFreezeCommandErrResponse
 func BuildFreezeCommandErrorResponse(prefix, address string) ([]byte, error) {
 	statute := &MeterDlt645Protocol{prefix: prefix, Address: address, ControlChar: FreezeCommandErrorResponse}
 	return statute.Encode()
 }
 
+// BuildFreezeCommandErrResponse 生成一个冻结命令的异常回复报文
+// prefix 通配唤醒前缀
+// Address 电表地址
+// errCode 错误码
+func BuildFreezeCommandErrResponse(prefix, address string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: address, ControlChar: FreezeCommandErrorResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildRelayControlRequest 生成一个跳合闸、报警、保电的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// pwd 密码
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+func BuildRelayControlRequest(prefix, meterId string, pwd Password, operatorCode []byte, cmd RelayCommand, deadline time.Time) ([]byte, error) {
+	if operatorCode == nil || len(operatorCode) != 4 {
+		return nil, errors.New("operatorCode length error")
+	}
+	data := append(pwd.Bytes(), operatorCode...)
+	//N1 控制命令类型，N2 保留
+	data = append(data, byte(cmd), 0x00)
+	//N3~N8 命令有效截止时间 ssmmhhDDMMYY
+	deadlineArr, err := EncodeTime(FormatYYMMDDhhmmss, deadline)
+	if err != nil {
+		return nil, err
+	}
+	data = append(data, deadlineArr...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: RelayControlRequest, Data: data}
+	return statute.Encode()
+}
+
+// BuildRelayControlResponse 生成一个跳合闸、报警、保电的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+func BuildRelayControlResponse(prefix, meterId string) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: RelayControlResponse}
+	return statute.Encode()
+}
+
+// BuildRelayControlErrResponse 生成一个跳合闸、报警、保电的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildRelayControlErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: RelayControlErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildDemandClearRequest 生成一个最大需量清零的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// pwd 密码
+// operatorCode 操作者代码
+func BuildDemandClearRequest(prefix, meterId string, pwd Password, operatorCode []byte) ([]byte, error) {
+	return buildClearRequest(prefix, meterId, DemandClearRequest, pwd, operatorCode, nil)
+}
+
+// BuildDemandClearResponse 生成一个最大需量清零的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+func BuildDemandClearResponse(prefix, meterId string) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: DemandClearResponse}
+	return statute.Encode()
+}
+
+// BuildDemandClearErrResponse 生成一个最大需量清零的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildDemandClearErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: DemandClearErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildMeterClearRequest 生成一个电表清零的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// pwd 密码
+// operatorCode 操作者代码
+func BuildMeterClearRequest(prefix, meterId string, pwd Password, operatorCode []byte) ([]byte, error) {
+	return buildClearRequest(prefix, meterId, MeterClearRequest, pwd, operatorCode, nil)
+}
+
+// BuildMeterClearResponse 生成一个电表清零的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+func BuildMeterClearResponse(prefix, meterId string) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: MeterClearResponse}
+	return statute.Encode()
+}
+
+// BuildMeterClearErrResponse 生成一个电表清零的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildMeterClearErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: MeterClearErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildEventClearRequest 生成一个事件清零的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 事件记录的数据标识，DI0 为 FF 时清该类事件，FFFFFFFF 表示清全部事件
+// pwd 密码
+// operatorCode 操作者代码
+func BuildEventClearRequest(prefix, meterId string, ident []byte, pwd Password, operatorCode []byte) ([]byte, error) {
+	if ident == nil || len(ident) != 4 {
+		return nil, errors.New("data ident length error")
+	}
+	return buildClearRequest(prefix, meterId, EventClearRequest, pwd, operatorCode, reverseBytes(ident))
+}
+
+// BuildEventClearResponse 生成一个事件清零的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+func BuildEventClearResponse(prefix, meterId string) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: EventClearResponse}
+	return statute.Encode()
+}
+
+// BuildEventClearErrResponse 生成一个事件清零的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildEventClearErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: EventClearErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// buildClearRequest 生成清零请求报文，数据域为密码、操作者代码和附加数据
+func buildClearRequest(prefix, meterId string, controlChar byte, pwd Password, operatorCode, extra []byte) ([]byte, error) {
+	if operatorCode == nil || len(operatorCode) != 4 {
+		return nil, errors.New("operatorCode length error")
+	}
+	data := append(pwd.Bytes(), operatorCode...)
+	data = append(data, extra...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: controlChar, Data: data}
+	return statute.Encode()
+}
+
+// BuildPasswordChangeRequest 生成一个修改密码的请求报文，数据标识由新密码的权限决定
+// prefix 通配唤醒前缀
+// meterId 表地址
+// old 原密码，权限不能低于新密码
+// new 新密码
+func BuildPasswordChangeRequest(prefix, meterId string, old, new Password) ([]byte, error) {
+	ident, err := passwordIdent(new.Level)
+	if err != nil {
+		return nil, err
+	}
+	data := append(reverseBytes(ident), old.Bytes()...)
+	data = append(data, new.Bytes()...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: PasswordChangeRequest, Data: data}
+	return statute.Encode()
+}
+
+// BuildPasswordChangeResponse 生成一个修改密码的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// pwd 新密码
+func BuildPasswordChangeResponse(prefix, meterId string, pwd Password) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: PasswordChangeResponse, Data: pwd.Bytes()}
+	return statute.Encode()
+}
+
+// BuildPasswordChangeErrResponse 生成一个修改密码的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildPasswordChangeErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: PasswordChangeErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildBaudChangeRequest 生成一个更改通信速率的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// rate 新的通信速率
+func BuildBaudChangeRequest(prefix, meterId string, rate int) ([]byte, error) {
+	feature, err := BaudRateFeature(rate)
+	if err != nil {
+		return nil, err
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: BaudChangeRequest, Data: []byte{feature}}
+	return statute.Encode()
+}
+
+// BuildBaudChangeResponse 生成一个更改通信速率的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// rate 变更后的通信速率
+func BuildBaudChangeResponse(prefix, meterId string, rate int) ([]byte, error) {
+	feature, err := BaudRateFeature(rate)
+	if err != nil {
+		return nil, err
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: BaudChangeResponse, Data: []byte{feature}}
+	return statute.Encode()
+}
+
+// BuildBaudChangeErrResponse 生成一个更改通信速率的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildBaudChangeErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: BaudChangeErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
+// BuildSecurityRequest 生成一个安全认证的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识，例如 IdentityAuthIdent
+// operatorCode 操作者代码
+// data 数据标识和操作者代码之后的数据
+func BuildSecurityRequest(prefix, meterId string, ident, operatorCode, data []byte) ([]byte, error) {
+	if ident == nil || len(ident) != 4 {
+		return nil, errors.New("data ident length error")
+	}
+	if operatorCode == nil || len(operatorCode) != 4 {
+		return nil, errors.New("operatorCode length error")
+	}
+	payload := append(reverseBytes(ident), operatorCode...)
+	payload = append(payload, data...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: SecurityRequest, Data: payload}
+	return statute.Encode()
+}
+
+// BuildIdentityAuthRequest 生成一个身份认证的请求报文，数据为密文1、随机数1和分散因子，返回报文和随机数1
+// prefix 通配唤醒前缀
+// meterId 表地址
+// operatorCode 操作者代码
+// module 安全模块
+// factor 分散因子，8字节
+func BuildIdentityAuthRequest(prefix, meterId string, operatorCode []byte, module SecurityModule, factor []byte) ([]byte, []byte, error) {
+	if module == nil {
+		return nil, nil, errors.New("security module is nil")
+	}
+	if len(factor) != 8 {
+		return nil, nil, errors.New("factor length error")
+	}
+	random1, err := module.Random(8)
+	if err != nil {
+		return nil, nil, err
+	}
+	cipher1, err := module.Encrypt(random1)
+	if err != nil {
+		return nil, nil, err
+	}
+	data := append(append(cipher1, random1...), factor...)
+	frame, err := BuildSecurityRequest(prefix, meterId, IdentityAuthIdent, operatorCode, data)
+	return frame, random1, err
+}
+
+// BuildSecurityResponse 生成一个安全认证的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识
+// data 数据标识之后的数据
+func BuildSecurityResponse(prefix, meterId string, ident, data []byte) ([]byte, error) {
+	if ident == nil || len(ident) != 4 {
+		return nil, errors.New("data ident length error")
+	}
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: SecurityResponse, Data: append(reverseBytes(ident), data...)}
+	return statute.Encode()
+}
+
+// BuildSecurityErrResponse 生成一个安全认证的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// serr 安全认证错误信息字
+func BuildSecurityErrResponse(prefix, meterId string, serr uint16) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: SecurityErrResponse, Data: []byte{byte(serr), byte(serr >> 8)}}
+	return statute.Encode()
+}
+
+// BuildSecureSetRequest 生成一个98级(密文+MAC)或99级(明文+MAC)写数据的请求报文，MAC 覆盖数据标识和数据
+// prefix 通配唤醒前缀
+// meterId 表地址
+// ident 数据标识
+// level 密码权限，98 或 99
+// operatorCode 操作者代码
+// value 数据，低字节在前
+// module 安全模块
+func BuildSecureSetRequest(prefix, meterId string, ident []byte, level byte, operatorCode, value []byte, module SecurityModule) ([]byte, error) {
+	if ident == nil || len(ident) != 4 {
+		return nil, errors.New("data ident length error")
+	}
+	payload, err := securePayload(module, level, reverseBytes(ident), value)
+	if err != nil {
+		return nil, err
+	}
+	return BuildMasterSetRequest[[]byte](prefix, meterId, ident, Password{Level: level}, operatorCode, &MeterData[[]byte]{Value: payload})
+}
+
+// BuildSecureRelayControlRequest 生成一个98级(密文+MAC)跳合闸、报警、保电的请求报文，N1~N8 加密传输
+// prefix 通配唤醒前缀
+// meterId 表地址
+// operatorCode 操作者代码
+// cmd 控制命令类型
+// deadline 命令有效截止时间
+// module 安全模块
+func BuildSecureRelayControlRequest(prefix, meterId string, operatorCode []byte, cmd RelayCommand, deadline time.Time, module SecurityModule) ([]byte, error) {
+	if operatorCode == nil || len(operatorCode) != 4 {
+		return nil, errors.New("operatorCode length error")
+	}
+	deadlineArr, err := EncodeTime(FormatYYMMDDhhmmss, deadline)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := securePayload(module, PasswordLevel98, nil, append([]byte{byte(cmd), 0x00}, deadlineArr...))
+	if err != nil {
+		return nil, err
+	}
+	data := append(Password{Level: PasswordLevel98}.Bytes(), operatorCode...)
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: RelayControlRequest, Data: append(data, payload...)}
+	return statute.Encode()
+}
+
+// BuildMultiOutputRequest 生成一个多功能端子输出控制的请求报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// output 输出信号类别
+func BuildMultiOutputRequest(prefix, meterId string, output MultiFunctionOutput) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: MultiOutputRequest, Data: []byte{byte(output)}}
+	return statute.Encode()
+}
+
+// BuildMultiOutputResponse 生成一个多功能端子输出控制的正常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// output 输出信号类别
+func BuildMultiOutputResponse(prefix, meterId string, output MultiFunctionOutput) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: MultiOutputResponse, Data: []byte{byte(output)}}
+	return statute.Encode()
+}
+
+// BuildMultiOutputErrResponse 生成一个多功能端子输出控制的异常应答报文
+// prefix 通配唤醒前缀
+// meterId 表地址
+// errCode 错误码
+func BuildMultiOutputErrResponse(prefix, meterId string, errCode MeterError) ([]byte, error) {
+	statute := &MeterDlt645Protocol{prefix: prefix, Address: meterId, ControlChar: MultiOutputErrResponse, Data: []byte{byte(errCode)}}
+	return statute.Encode()
+}
+
 func reverseBytes(original []byte) []byte {
 	length := len(original)
 	reversed := make([]byte, length)
@@ -0,0 +1,84 @@
+package go_dlt645_2007
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// setRecorder 记录 MasterDataCodec 解析出的写数据和冻结请求
+type setRecorder struct {
+	MasterDataReceiver
+	ident    []byte
+	pwd      Password
+	operator []byte
+	data     []byte
+	freeze   []byte
+	err      error
+}
+
+func (r *setRecorder) MasterSetRequest(ident []byte, pwd Password, operator []byte, data []byte) {
+	r.ident, r.pwd, r.operator, r.data = ident, pwd, operator, data
+}
+
+func (r *setRecorder) FreezeCommand(mm, hh, DD, MM byte) {
+	r.freeze = []byte{mm, hh, DD, MM}
+}
+
+func (r *setRecorder) ErrorData(funcCode byte, data []byte, err error) {
+	r.err = err
+}
+
+func TestBuildMasterSetRequest(t *testing.T) {
+	pwd := Password{Level: PasswordLevel02, Code: [3]byte{0x56, 0x34, 0x12}}
+	operator := []byte{0x01, 0x02, 0x03, 0x04}
+	frame, err := BuildMasterSetRequest("", "000000013310", []byte{0x04, 0x00, 0x01, 0x03}, pwd, operator, &MeterData[string]{Value: "1234"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pro := &MeterDlt645Protocol{}
+	if err = pro.Decode(frame); err != nil {
+		t.Fatal(err)
+	}
+	//DI0~DI3(4) + 密码(4) + 操作者代码(4) + 数据(2)，没有长度字节
+	if len(pro.Data) != 14 {
+		t.Fatalf("data length %d, want 14", len(pro.Data))
+	}
+	recorder := &setRecorder{}
+	NewMasterDataCodec(recorder).ParseData(pro.ControlChar, pro.Data)
+	if recorder.err != nil {
+		t.Fatal(recorder.err)
+	}
+	if !bytes.Equal(reverseBytes(recorder.ident), []byte{0x04, 0x00, 0x01, 0x03}) {
+		t.Fatalf("ident % x", recorder.ident)
+	}
+	if recorder.pwd != pwd || !bytes.Equal(recorder.operator, operator) {
+		t.Fatalf("pwd %v operator % x", recorder.pwd, recorder.operator)
+	}
+	if !bytes.Equal(recorder.data, []byte{0x34, 0x12}) {
+		t.Fatalf("data % x", recorder.data)
+	}
+}
+
+func TestBuildFreezeCommandRequest(t *testing.T) {
+	//各字段都不小于10，BCD 码与二进制的报文不同
+	ti := time.Date(2024, 12, 31, 23, 59, 0, 0, time.Local)
+	for address, expected := range map[string]string{"000000013310": "000000013310", "": BroadcastAddress} {
+		frame, err := BuildFreezeCommandRequest("", address, ti)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pro := &MeterDlt645Protocol{}
+		if err = pro.Decode(frame); err != nil {
+			t.Fatal(err)
+		}
+		if pro.Address != expected {
+			t.Fatalf("address %s, want %s", pro.Address, expected)
+		}
+		recorder := &setRecorder{}
+		NewMasterDataCodec(recorder).ParseData(pro.ControlChar, pro.Data)
+		if !bytes.Equal(recorder.freeze, []byte{0x59, 0x23, 0x31, 0x12}) {
+			t.Fatalf("freeze % x", recorder.freeze)
+		}
+	}
+}